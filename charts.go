@@ -18,32 +18,8 @@ func (r *unicodeChartRenderer) renderChart(stats Stats, config Config) string {
 	var sb strings.Builder
 	sb.WriteString("\n📊 File Size Distribution Chart:\n")
 	
-	// Convert map to slice for sorting
-	type TypeInfo struct {
-		Ext  string
-		Size int64
-	}
-	
-	typeInfos := make([]TypeInfo, 0, len(stats.FileTypes))
-	for ext, size := range stats.FileTypes {
-		if ext == "" {
-			ext = "(no extension)"
-		}
-		typeInfos = append(typeInfos, TypeInfo{ext, size})
-	}
-	
-	// Sort by size
-	sort.Slice(typeInfos, func(i, j int) bool {
-		return typeInfos[i].Size > typeInfos[j].Size
-	})
-	
-	// Limit to top items for the chart
-	limit := config.StatsCount
-	if limit > len(typeInfos) {
-		limit = len(typeInfos)
-	}
-	
-	typeInfos = typeInfos[:limit]
+	// Largest file types first, limited to the top items for the chart
+	typeInfos := fileTypeDistribution(stats, config.StatsCount)
 	
 	// Find the maximum size for scaling
 	maxSize := int64(0)
@@ -85,32 +61,8 @@ func (r *asciiChartRenderer) renderChart(stats Stats, config Config) string {
 	var sb strings.Builder
 	sb.WriteString("\n# File Size Distribution Chart:\n")
 	
-	// Convert map to slice for sorting
-	type TypeInfo struct {
-		Ext  string
-		Size int64
-	}
-	
-	typeInfos := make([]TypeInfo, 0, len(stats.FileTypes))
-	for ext, size := range stats.FileTypes {
-		if ext == "" {
-			ext = "(no extension)"
-		}
-		typeInfos = append(typeInfos, TypeInfo{ext, size})
-	}
-	
-	// Sort by size
-	sort.Slice(typeInfos, func(i, j int) bool {
-		return typeInfos[i].Size > typeInfos[j].Size
-	})
-	
-	// Limit to top items for the chart
-	limit := config.StatsCount
-	if limit > len(typeInfos) {
-		limit = len(typeInfos)
-	}
-	
-	typeInfos = typeInfos[:limit]
+	// Largest file types first, limited to the top items for the chart
+	typeInfos := fileTypeDistribution(stats, config.StatsCount)
 	
 	// Find the maximum size for scaling
 	maxSize := int64(0)
@@ -156,32 +108,8 @@ func (r *sparklineChartRenderer) renderChart(stats Stats, config Config) string
 	// from lowest to highest: ▁ ▂ ▃ ▄ ▅ ▆ ▇ █
 	sparkChars := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
 	
-	// Convert map to slice for sorting
-	type TypeInfo struct {
-		Ext  string
-		Size int64
-	}
-	
-	typeInfos := make([]TypeInfo, 0, len(stats.FileTypes))
-	for ext, size := range stats.FileTypes {
-		if ext == "" {
-			ext = "(no extension)"
-		}
-		typeInfos = append(typeInfos, TypeInfo{ext, size})
-	}
-	
-	// Sort by size
-	sort.Slice(typeInfos, func(i, j int) bool {
-		return typeInfos[i].Size > typeInfos[j].Size
-	})
-	
-	// Limit to top items for the chart
-	limit := config.StatsCount
-	if limit > len(typeInfos) {
-		limit = len(typeInfos)
-	}
-	
-	typeInfos = typeInfos[:limit]
+	// Largest file types first, limited to the top items for the chart
+	typeInfos := fileTypeDistribution(stats, config.StatsCount)
 	
 	// Find the maximum size for scaling
 	maxSize := int64(0)
@@ -201,8 +129,7 @@ func (r *sparklineChartRenderer) renderChart(stats Stats, config Config) string
 			level = len(sparkChars) - 1
 		}
 		
-		// Generate a 20-character sparkline where only the relevant part is filled
-		sparkline := strings.Repeat(" ", 20)
+		// Pick the sparkline character for this level
 		sparkChar := string(sparkChars[level])
 		
 		percentage := float64(info.Size) / float64(stats.TotalSize) * 100
@@ -217,6 +144,37 @@ func (r *sparklineChartRenderer) renderChart(stats Stats, config Config) string
 	return sb.String()
 }
 
+// typeSize is the total size of the files with one extension
+type typeSize struct {
+	Ext  string
+	Size int64
+}
+
+// Get the total size per file type, largest first and limited to a number
+// of types (-1 for all). Files without an extension are labeled as such.
+func fileTypeDistribution(stats Stats, limit int) []typeSize {
+	typeInfos := make([]typeSize, 0, len(stats.FileTypes))
+	for ext, size := range stats.FileTypes {
+		if ext == "" {
+			ext = "(no extension)"
+		}
+		typeInfos = append(typeInfos, typeSize{ext, size})
+	}
+
+	// Sort by size, then by name so equal sizes keep a stable order
+	sort.Slice(typeInfos, func(i, j int) bool {
+		if typeInfos[i].Size != typeInfos[j].Size {
+			return typeInfos[i].Size > typeInfos[j].Size
+		}
+		return typeInfos[i].Ext < typeInfos[j].Ext
+	})
+
+	if limit >= 0 && limit < len(typeInfos) {
+		typeInfos = typeInfos[:limit]
+	}
+	return typeInfos
+}
+
 // getChartRenderer returns the appropriate chart renderer based on config
 func getChartRenderer(config Config) chartRenderer {
 	if config.Unicode {
@@ -83,3 +83,23 @@ func TestGetChartRenderer(t *testing.T) {
 		t.Errorf("getChartRenderer with Unicode=false should return asciiChartRenderer")
 	}
 }
+
+func TestChartEqualSizes(t *testing.T) {
+	stats := Stats{
+		TotalSize: 400,
+		FileTypes: map[string]int64{".go": 100, ".md": 100, ".txt": 100, "": 100},
+	}
+	config := Config{StatsCount: 10}
+
+	// Types of equal size are listed by name, on every run
+	expected := "\n# File Size Distribution Chart:\n" +
+		"  (no extension)  [##################################################]   25.0% (100 B)\n" +
+		"  .go             [##################################################]   25.0% (100 B)\n" +
+		"  .md             [##################################################]   25.0% (100 B)\n" +
+		"  .txt            [##################################################]   25.0% (100 B)\n"
+	for i := 0; i < 10; i++ {
+		if output := getChartRenderer(config).renderChart(stats, config); output != expected {
+			t.Fatalf("Unexpected chart:\n%s\nexpected:\n%s", output, expected)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Format used by the modification time column
+const mtimeLayout = "2006-01-02 15:04"
+
+// Width of the size bars drawn in disk usage mode
+const duBarWidth = 20
+
+// columnFormatter renders the metadata columns shown before entry names,
+// padding every column to the widest value in the tree
+type columnFormatter struct {
+	config      Config
+	widths      []int
+	parentSizes map[*Node]int64
+}
+
+// Create a column formatter sized for all entries below root. Returns nil
+// when no columns are enabled.
+func newColumnFormatter(root *Node, config Config) *columnFormatter {
+	if !(config.ShowInode || config.ShowPerms || config.ShowOwner ||
+		config.ShowSize || config.ShowDirSize || config.ShowMtime || config.GitAge || config.DiskUsage) {
+		return nil
+	}
+
+	f := &columnFormatter{
+		config:      config,
+		parentSizes: map[*Node]int64{root: root.Size},
+	}
+	var measure func(node *Node)
+	measure = func(node *Node) {
+		for i, value := range f.values(node) {
+			if i == len(f.widths) {
+				f.widths = append(f.widths, 0)
+			}
+			if width := utf8.RuneCountInString(value); width > f.widths[i] {
+				f.widths[i] = width
+			}
+		}
+		for _, child := range node.Children {
+			f.parentSizes[child] = node.Size
+			measure(child)
+		}
+	}
+
+	// The root only shows columns in disk usage mode
+	if config.DiskUsage {
+		measure(root)
+	} else {
+		for _, child := range root.Children {
+			measure(child)
+		}
+	}
+	return f
+}
+
+// Get the column values of a node, in display order
+func (f *columnFormatter) values(node *Node) []string {
+	var values []string
+
+	if f.config.ShowInode {
+		if node.Sys != nil {
+			values = append(values, strconv.FormatUint(node.Sys.Inode, 10))
+		} else {
+			values = append(values, "-")
+		}
+	}
+
+	if f.config.ShowPerms {
+		if node.Err != nil {
+			values = append(values, "-")
+		} else {
+			values = append(values, node.Mode.String())
+		}
+	}
+
+	if f.config.ShowOwner {
+		if node.Sys != nil {
+			values = append(values, lookupUserName(node.Sys.UID), lookupGroupName(node.Sys.GID))
+		} else {
+			values = append(values, "-", "-")
+		}
+	}
+
+	if f.config.ShowSize || f.config.ShowDirSize {
+		switch {
+		case node.Err != nil:
+			values = append(values, "-")
+		case node.IsDir() && !f.config.ShowDirSize, !node.IsDir() && !f.config.ShowSize:
+			values = append(values, "")
+		default:
+			values = append(values, formatSize(node.Size))
+		}
+	}
+
+	if f.config.ShowMtime {
+		if node.ModTime.IsZero() {
+			values = append(values, "-")
+		} else {
+			values = append(values, node.ModTime.Format(mtimeLayout))
+		}
+	}
+
+	if f.config.GitAge {
+		values = append(values, historyValues(node)...)
+	}
+
+	if f.config.DiskUsage {
+		values = append(values, f.duValues(node)...)
+	}
+
+	return values
+}
+
+// Get the disk usage columns of a node: a size bar, the percentage of the
+// parent directory, the size and the number of files below a directory
+func (f *columnFormatter) duValues(node *Node) []string {
+	parentSize := f.parentSizes[node]
+	fraction := 0.0
+	if parentSize > 0 {
+		fraction = float64(node.Size) / float64(parentSize)
+	}
+
+	barChar := "#"
+	if f.config.Unicode {
+		barChar = "█"
+	}
+	filled := int(fraction*duBarWidth + 0.5)
+	bar := strings.Repeat(barChar, filled) + strings.Repeat(" ", duBarWidth-filled)
+
+	files := ""
+	if node.IsDir() {
+		files = fmt.Sprintf("%d files", node.FileCount)
+		if node.FileCount == 1 {
+			files = "1 file"
+		}
+	}
+
+	return []string{bar, fmt.Sprintf("%.1f%%", fraction*100), formatSize(node.Size), files}
+}
+
+// Format the columns of a node as "[a b c]  ", right-aligning each value
+func (f *columnFormatter) format(node *Node) string {
+	if f == nil {
+		return ""
+	}
+
+	values := f.values(node)
+	for i, value := range values {
+		values[i] = strings.Repeat(" ", f.widths[i]-utf8.RuneCountInString(value)) + value
+	}
+	return "[" + strings.Join(values, " ") + "]  "
+}
+
+// Caches of user and group names, shared by all renderers
+var (
+	namesMu    sync.Mutex
+	userNames  = map[uint32]string{}
+	groupNames = map[uint32]string{}
+)
+
+// Look up a user name, falling back to the numeric id
+func lookupUserName(uid uint32) string {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	if name, ok := userNames[uid]; ok {
+		return name
+	}
+	name := strconv.FormatUint(uint64(uid), 10)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	userNames[uid] = name
+	return name
+}
+
+// Look up a group name, falling back to the numeric id
+func lookupGroupName(gid uint32) string {
+	namesMu.Lock()
+	defer namesMu.Unlock()
+
+	if name, ok := groupNames[gid]; ok {
+		return name
+	}
+	name := strconv.FormatUint(uint64(gid), 10)
+	if g, err := user.LookupGroupId(name); err == nil {
+		name = g.Name
+	}
+	groupNames[gid] = name
+	return name
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestColumnFormatter(t *testing.T) {
+	mtime := time.Date(2024, 3, 5, 14, 30, 0, 0, time.Local)
+	root := &Node{Name: "root", Mode: fs.ModeDir, Children: []*Node{
+		{Name: "src", Mode: fs.ModeDir | 0755, Size: 2048, ModTime: mtime, Children: []*Node{
+			{Name: "main.go", Mode: 0644, Size: 2048, ModTime: mtime},
+		}},
+		{Name: "a.txt", Mode: 0600, Size: 12, ModTime: mtime},
+		{Name: "broken", Err: errors.New("permission denied")},
+	}}
+
+	if f := newColumnFormatter(root, Config{}); f != nil {
+		t.Errorf("Expected no column formatter without enabled columns")
+	}
+
+	f := newColumnFormatter(root, Config{ShowSize: true, ShowPerms: true, ShowMtime: true})
+	tests := []struct {
+		node     *Node
+		expected string
+	}{
+		{root.Children[0], "[drwxr-xr-x        2024-03-05 14:30]  "},
+		{root.Children[0].Children[0], "[-rw-r--r-- 2.0 KB 2024-03-05 14:30]  "},
+		{root.Children[1], "[-rw-------   12 B 2024-03-05 14:30]  "},
+		{root.Children[2], "[         -      -                -]  "},
+	}
+	for _, test := range tests {
+		result := f.format(test.node)
+		if result != test.expected {
+			t.Errorf("format(%s): expected %q, got %q", test.node.Name, test.expected, result)
+		}
+	}
+
+	f = newColumnFormatter(root, Config{ShowDirSize: true})
+	if result := f.format(root.Children[0]); result != "[2.0 KB]  " {
+		t.Errorf("format(src) with directory sizes: expected %q, got %q", "[2.0 KB]  ", result)
+	}
+	if result := f.format(root.Children[1]); result != "[      ]  " {
+		t.Errorf("format(a.txt) with directory sizes only: expected %q, got %q", "[      ]  ", result)
+	}
+}
+
+func TestColumnFormatterNil(t *testing.T) {
+	var f *columnFormatter
+	if result := f.format(&Node{Name: "x"}); result != "" {
+		t.Errorf("Expected empty columns from a nil formatter, got %q", result)
+	}
+}
+
+func TestColumnFormatterDiskUsage(t *testing.T) {
+	root := &Node{Name: "root", Mode: fs.ModeDir, Children: []*Node{
+		{Name: "big", Mode: fs.ModeDir, Children: []*Node{
+			{Name: "a.bin", Size: 3072},
+		}},
+		{Name: "small", Mode: fs.ModeDir, Children: []*Node{
+			{Name: "b.bin", Size: 1024},
+		}},
+	}}
+	sumTotals(root)
+
+	f := newColumnFormatter(root, Config{DiskUsage: true})
+	tests := []struct {
+		node     *Node
+		expected string
+	}{
+		{root, "[#################### 100.0% 4.0 KB 2 files]  "},
+		{root.Children[0], "[###############       75.0% 3.0 KB  1 file]  "},
+		{root.Children[1], "[#####                 25.0% 1.0 KB  1 file]  "},
+		{root.Children[0].Children[0], "[#################### 100.0% 3.0 KB        ]  "},
+	}
+	for _, test := range tests {
+		result := f.format(test.node)
+		if result != test.expected {
+			t.Errorf("format(%s): expected %q, got %q", test.node.Name, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Names of the configuration file looked up in the scanned directory and
+// its parents, in order of preference
+var configFileNames = []string{".hyperion.yaml", ".hyperion.yml", ".hyperion.toml"}
+
+// Names of the configuration file in the user config directory
+var userConfigFileNames = []string{"config.yaml", "config.yml", "config.toml"}
+
+// Prefix of the environment variables that set flags
+const envPrefix = "HYPERION_"
+
+// Flags that accept several values; a list in a configuration file sets
+// each value instead of joining them with commas
+var repeatableFlags = map[string]bool{
+	"include":       true,
+	"exclude":       true,
+	"include-regex": true,
+	"exclude-regex": true,
+}
+
+// configFile holds the settings read from one configuration file. Keys are
+// flag names.
+type configFile struct {
+	path     string
+	settings map[string]interface{}
+	profiles map[string]map[string]interface{}
+}
+
+// Apply settings for the flags not given on the command line, first from
+// HYPERION_* environment variables, then from the selected profile and
+// finally from the top level of the configuration files
+func (f *configFlags) applySettings() error {
+	for _, name := range f.names {
+		if value, ok := os.LookupEnv(envName(name)); ok && !f.isSet(name) {
+			if err := f.fs.Set(name, value); err != nil {
+				return fmt.Errorf("invalid value %q for %s: %v", value, envName(name), err)
+			}
+		}
+	}
+
+	if value, ok := os.LookupEnv(envName("profile")); ok && !f.isSet("profile") {
+		f.profile = value
+	}
+
+	files, err := loadConfigFiles(f.config.Path)
+	if err != nil {
+		return err
+	}
+
+	// A path set by a file moves the scan, so the files are looked up again
+	// from the directory actually scanned. They come first, before the
+	// files found so far.
+	if file, value, ok := configuredPath(files, f.profile); ok && !f.isSet("path") {
+		if err := f.applyFileSettings(file.path, map[string]interface{}{"path": value}); err != nil {
+			return err
+		}
+		scanned, err := loadConfigFiles(f.config.Path)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if !containsConfigFile(scanned, file.path) {
+				scanned = append(scanned, file)
+			}
+		}
+		files = scanned
+	}
+
+	if f.profile != "" {
+		found := false
+		for _, file := range files {
+			if settings, ok := file.profiles[f.profile]; ok {
+				found = true
+				if err := f.applyFileSettings(file.path, settings); err != nil {
+					return err
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("profile %q not found in any configuration file", f.profile)
+		}
+	}
+
+	for _, file := range files {
+		if err := f.applyFileSettings(file.path, file.settings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Find the scan path set by the files: in the selected profile first, then
+// at the top level, nearest file first
+func configuredPath(files []*configFile, profile string) (*configFile, interface{}, bool) {
+	if profile != "" {
+		for _, file := range files {
+			if value, ok := file.profiles[profile]["path"]; ok {
+				return file, value, true
+			}
+		}
+	}
+	for _, file := range files {
+		if value, ok := file.settings["path"]; ok {
+			return file, value, true
+		}
+	}
+	return nil, nil, false
+}
+
+// Apply the settings of one file for the flags that are still unset
+func (f *configFlags) applyFileSettings(path string, settings map[string]interface{}) error {
+	// Sorted for stable error messages
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !f.isConfigurable(name) {
+			return fmt.Errorf("unknown setting %q in %s", name, path)
+		}
+		if f.isSet(name) {
+			continue
+		}
+
+		values, err := settingValues(settings[name], repeatableFlags[name])
+		if err != nil {
+			return fmt.Errorf("invalid value for %q in %s: %v", name, path, err)
+		}
+		for _, value := range values {
+			if err := f.fs.Set(name, value); err != nil {
+				return fmt.Errorf("invalid value for %q in %s: %v", name, path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Check whether a flag can be set from the environment or a file
+func (f *configFlags) isConfigurable(name string) bool {
+	for _, configurable := range f.names {
+		if name == configurable {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the environment variable of a flag, e.g. HYPERION_SHOW_FILES
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Convert a setting to flag values. Lists are joined with commas, or kept
+// as separate values for repeatable flags.
+func settingValues(value interface{}, repeatable bool) ([]string, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		s, err := settingString(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		s, err := settingString(item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, s)
+	}
+	if repeatable {
+		return values, nil
+	}
+	return []string{strings.Join(values, ",")}, nil
+}
+
+// Convert a scalar setting to a flag value
+func settingString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("expected a string, number, boolean or list")
+	}
+}
+
+// Find and load the configuration files that apply to a scan path, nearest
+// first: the scanned directory, its parents, then the user config directory
+func loadConfigFiles(scanPath string) ([]*configFile, error) {
+	var files []*configFile
+
+	dir, err := filepath.Abs(scanPath)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		file, err := loadFirstConfigFile(dir, configFileNames)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			files = append(files, file)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if userDir, err := os.UserConfigDir(); err == nil {
+		file, err := loadFirstConfigFile(filepath.Join(userDir, "hyperion"), userConfigFileNames)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil && !containsConfigFile(files, file.path) {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// Load the first readable file of a directory among the given names. Files
+// that are missing or cannot be read, such as in a directory without
+// permissions, are skipped; only a file that was read can fail to parse.
+func loadFirstConfigFile(dir string, names []string) (*configFile, error) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		return parseConfigFile(path, data)
+	}
+	return nil, nil
+}
+
+// Check whether a file is already in the list
+func containsConfigFile(files []*configFile, path string) bool {
+	for _, file := range files {
+		if file.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse a YAML or TOML configuration file. Profiles are tables under the
+// "profiles" key.
+func parseConfigFile(path string, data []byte) (*configFile, error) {
+	raw := make(map[string]interface{})
+	var err error
+	if filepath.Ext(path) == ".toml" {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %v", path, err)
+	}
+
+	file := &configFile{
+		path:     path,
+		settings: raw,
+		profiles: make(map[string]map[string]interface{}),
+	}
+
+	if profiles, ok := raw["profiles"]; ok {
+		delete(raw, "profiles")
+		table, ok := profiles.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid profiles in %s: expected a table of profiles", path)
+		}
+		for name, settings := range table {
+			profile, ok := settings.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid profile %q in %s: expected a table of settings", name, path)
+			}
+			file.profiles[name] = profile
+		}
+	}
+
+	return file, nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Create a directory with a configuration file, isolated from the user
+// config directory and HYPERION_* variables of the environment
+func createConfigDir(t *testing.T, name, content string) string {
+	dir := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, envPrefix) {
+			key := strings.SplitN(env, "=", 2)[0]
+			t.Setenv(key, "")
+			os.Unsetenv(key)
+		}
+	}
+
+	if name != "" {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// Parse command line arguments and resolve them with the configuration
+func resolveArgs(t *testing.T, args ...string) (Config, error) {
+	fs := flag.NewFlagSet("hyperion", flag.ContinueOnError)
+	flags := registerConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return flags.resolve()
+}
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		flag     string
+		expected string
+	}{
+		{"unicode", "HYPERION_UNICODE"},
+		{"show-files", "HYPERION_SHOW_FILES"},
+		{"exclude-regex", "HYPERION_EXCLUDE_REGEX"},
+	}
+
+	for _, test := range tests {
+		if result := envName(test.flag); result != test.expected {
+			t.Errorf("envName(%q): expected %q, got %q", test.flag, test.expected, result)
+		}
+	}
+}
+
+func TestSettingValues(t *testing.T) {
+	tests := []struct {
+		value      interface{}
+		repeatable bool
+		expected   []string
+	}{
+		{"size", false, []string{"size"}},
+		{true, false, []string{"true"}},
+		{3, false, []string{"3"}},
+		{int64(3), false, []string{"3"}},
+		{[]interface{}{"bin", "obj"}, false, []string{"bin,obj"}},
+		{[]interface{}{"*.go", "*.md"}, true, []string{"*.go", "*.md"}},
+	}
+
+	for _, test := range tests {
+		result, err := settingValues(test.value, test.repeatable)
+		if err != nil {
+			t.Errorf("settingValues(%v): unexpected error: %v", test.value, err)
+			continue
+		}
+		if strings.Join(result, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("settingValues(%v): expected %q, got %q", test.value, test.expected, result)
+		}
+	}
+
+	if _, err := settingValues(map[string]interface{}{"a": 1}, false); err == nil {
+		t.Error("settingValues(table): expected an error")
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+	}{
+		{".hyperion.yaml", "sort: size\nexclude-folders: [bin, obj]\nprofiles:\n  ci:\n    unicode: false\n"},
+		{".hyperion.toml", "sort = \"size\"\nexclude-folders = [\"bin\", \"obj\"]\n\n[profiles.ci]\nunicode = false\n"},
+	}
+
+	for _, test := range tests {
+		file, err := parseConfigFile(test.path, []byte(test.content))
+		if err != nil {
+			t.Errorf("parseConfigFile(%q): unexpected error: %v", test.path, err)
+			continue
+		}
+		if file.settings["sort"] != "size" {
+			t.Errorf("parseConfigFile(%q): expected sort size, got %v", test.path, file.settings["sort"])
+		}
+		if _, ok := file.settings["profiles"]; ok {
+			t.Errorf("parseConfigFile(%q): expected profiles to be removed from the settings", test.path)
+		}
+		if file.profiles["ci"]["unicode"] != false {
+			t.Errorf("parseConfigFile(%q): expected ci profile with unicode false, got %v", test.path, file.profiles["ci"])
+		}
+	}
+
+	if _, err := parseConfigFile(".hyperion.yaml", []byte("profiles: [ci]\n")); err == nil {
+		t.Error("parseConfigFile(profile list): expected an error")
+	}
+}
+
+func TestConfigFileSettings(t *testing.T) {
+	dir := createConfigDir(t, ".hyperion.yaml", `
+sort: size
+max-depth: 2
+exclude-folders: [bin, obj]
+include: ["*.go", "*.md"]
+`)
+
+	config, err := resolveArgs(t, "--path", dir)
+	if err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+	if config.Sort != "size" {
+		t.Errorf("sort: expected %q, got %q", "size", config.Sort)
+	}
+	if config.MaxDepth != 2 {
+		t.Errorf("max-depth: expected 2, got %d", config.MaxDepth)
+	}
+	if strings.Join(config.ExcludeFolders, ",") != "bin,obj" {
+		t.Errorf("exclude-folders: expected [bin obj], got %v", config.ExcludeFolders)
+	}
+	if strings.Join(config.Include, ",") != "*.go,*.md" {
+		t.Errorf("include: expected [*.go *.md], got %v", config.Include)
+	}
+}
+
+func TestConfigFileInParent(t *testing.T) {
+	dir := createConfigDir(t, ".hyperion.toml", "max-depth = 1\n")
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, ".hyperion.yaml"), []byte("sort: size\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := resolveArgs(t, "--path", sub)
+	if err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+	if config.Sort != "size" || config.MaxDepth != 1 {
+		t.Errorf("expected sort size and max-depth 1 from both files, got %q and %d", config.Sort, config.MaxDepth)
+	}
+}
+
+func TestConfigFileUnreadable(t *testing.T) {
+	dir := createConfigDir(t, ".hyperion.yaml", "max-depth: 1\n")
+
+	// A scanned file is not a directory that can hold configuration files
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := resolveArgs(t, "--path", file)
+	if err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+	if config.MaxDepth != 1 {
+		t.Errorf("max-depth: expected 1 from the parent, got %d", config.MaxDepth)
+	}
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+	locked := filepath.Join(dir, "locked")
+	userDir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "hyperion")
+	for _, d := range []string{locked, userDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(d, ".hyperion.yaml"), []byte("sort: size\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(d, "config.yaml"), []byte("sort: size\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(d, 0); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chmod(d, 0755)
+	}
+
+	config, err = resolveArgs(t, "--path", locked)
+	if err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+	if config.MaxDepth != 1 || config.Sort == "size" {
+		t.Errorf("expected only the readable parent file, got max-depth %d and sort %q", config.MaxDepth, config.Sort)
+	}
+}
+
+func TestConfigFileInConfiguredPath(t *testing.T) {
+	scanned := createConfigDir(t, ".hyperion.yaml", "sort: size\n")
+	userDir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "hyperion")
+	if err := os.MkdirAll(userDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		args    []string
+	}{
+		{"top level", "path: " + scanned + "\nmax-depth: 1\n", nil},
+		{"profile", "max-depth: 1\nprofiles:\n  other:\n    path: " + scanned + "\n", []string{"--profile", "other"}},
+	}
+	for _, test := range tests {
+		if err := os.WriteFile(filepath.Join(userDir, "config.yaml"), []byte(test.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// The files of the scanned path apply, as well as the first ones
+		config, err := resolveArgs(t, test.args...)
+		if err != nil {
+			t.Fatalf("%s: resolve: unexpected error: %v", test.name, err)
+		}
+		if config.Path != scanned || config.Sort != "size" || config.MaxDepth != 1 {
+			t.Errorf("%s: expected path %s, sort size and max-depth 1, got %s, %q and %d",
+				test.name, scanned, config.Path, config.Sort, config.MaxDepth)
+		}
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	dir := createConfigDir(t, ".hyperion.yaml", `
+sort: size
+max-depth: 1
+stats-count: 3
+jobs: 2
+profiles:
+  ci:
+    max-depth: 4
+    stats-count: 5
+    jobs: 6
+`)
+	t.Setenv("HYPERION_STATS_COUNT", "7")
+	t.Setenv("HYPERION_JOBS", "8")
+
+	config, err := resolveArgs(t, "--path", dir, "--profile", "ci", "--jobs", "9")
+	if err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected int
+		result   int
+	}{
+		{"max-depth (profile over file)", 4, config.MaxDepth},
+		{"stats-count (environment over profile)", 7, config.StatsCount},
+		{"jobs (flag over environment)", 9, config.Jobs},
+	}
+	for _, test := range tests {
+		if test.result != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, test.result)
+		}
+	}
+	if config.Sort != "size" {
+		t.Errorf("sort (file): expected %q, got %q", "size", config.Sort)
+	}
+}
+
+func TestConfigProfileFromEnv(t *testing.T) {
+	dir := createConfigDir(t, ".hyperion.yaml", "profiles:\n  ci:\n    compact: true\n")
+	t.Setenv("HYPERION_PROFILE", "ci")
+
+	config, err := resolveArgs(t, "--path", dir)
+	if err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+	if !config.Compact {
+		t.Error("expected the ci profile to enable compact")
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		args    []string
+		message string
+	}{
+		{"colour: true\n", nil, `unknown setting "colour"`},
+		{"max-depth: deep\n", nil, `invalid value for "max-depth"`},
+		{"sort: size\n", []string{"--profile", "ci"}, `profile "ci" not found`},
+		{"profile: ci\n", nil, `unknown setting "profile"`},
+	}
+
+	for _, test := range tests {
+		dir := createConfigDir(t, ".hyperion.yaml", test.content)
+		_, err := resolveArgs(t, append([]string{"--path", dir}, test.args...)...)
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("resolve(%q): expected error containing %q, got %v", test.content, test.message, err)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+// diffStatus describes how an entry changed between two trees
+type diffStatus int
+
+const (
+	diffUnchanged diffStatus = iota
+	diffAdded
+	diffRemoved
+	diffModified
+	diffResized
+)
+
+// diffEntry is a node of the tree comparing two snapshots
+type diffEntry struct {
+	Name     string
+	IsDir    bool
+	Status   diffStatus
+	OldSize  int64
+	NewSize  int64
+	Children []*diffEntry
+}
+
+// Run the diff subcommand. Exits with 0 when the trees are identical, 1
+// when they differ and 2 on errors, like diff(1).
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags := registerConfigFlags(fs)
+	showUnchanged := fs.Bool("unchanged", false, "Also show entries that did not change")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: hyperion diff [flags] <old snapshot|path> <new snapshot|path>")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	// The configuration files are looked up from the new tree, not from
+	// the current directory
+	if !flags.isSet("path") {
+		fs.Set("path", operandDir(fs.Arg(1)))
+	}
+
+	config, err := flags.resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	color.NoColor = !config.Color
+
+	oldSnap, err := loadSnapshotOrScan(fs.Arg(0), config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	newSnap, err := loadSnapshotOrScan(fs.Arg(1), config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	root := diffTrees(oldSnap.Root, newSnap.Root)
+	treeChars := getTreeChars(config.Unicode, config.Compact)
+	renderDiff(color.Output, root, config, treeChars, *showUnchanged)
+
+	if root.Status != diffUnchanged {
+		return 1
+	}
+	return 0
+}
+
+// Get the directory of a diff operand: the directory itself, or the one
+// holding the snapshot file
+func operandDir(path string) string {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return filepath.Dir(path)
+	}
+	return path
+}
+
+// Load a snapshot file, or take a snapshot of a directory
+func loadSnapshotOrScan(path string, config Config) (*snapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return loadSnapshot(path)
+	}
+
+	config.Path = path
+	snap, err := takeSnapshot(config)
+	if isGitError(err) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error accessing path %s: %v", path, err)
+	}
+	return snap, nil
+}
+
+// Compare two snapshot trees
+func diffTrees(oldNode *jsonNode, newNode *jsonNode) *diffEntry {
+	name := newNode.Name
+	if oldNode.Name != newNode.Name {
+		name = oldNode.Name + " -> " + newNode.Name
+	}
+
+	entry := &diffEntry{
+		Name:     name,
+		IsDir:    true,
+		OldSize:  oldNode.Size,
+		NewSize:  newNode.Size,
+		Children: diffChildren(oldNode.Children, newNode.Children),
+	}
+	entry.Status = dirStatus(entry.Children)
+	return entry
+}
+
+// Compare the children of two directories, matching entries by name
+func diffChildren(oldChildren []*jsonNode, newChildren []*jsonNode) []*diffEntry {
+	oldByName := make(map[string]*jsonNode, len(oldChildren))
+	for _, child := range oldChildren {
+		oldByName[child.Name] = child
+	}
+
+	var entries []*diffEntry
+	seen := make(map[string]bool, len(newChildren))
+	for _, newChild := range newChildren {
+		seen[newChild.Name] = true
+		oldChild, ok := oldByName[newChild.Name]
+
+		switch {
+		case !ok:
+			entries = append(entries, markTree(newChild, diffAdded))
+		case (oldChild.Type == "directory") != (newChild.Type == "directory"):
+			// A file replaced by a directory, or the other way around
+			entries = append(entries, markTree(oldChild, diffRemoved), markTree(newChild, diffAdded))
+		case newChild.Type == "directory":
+			entry := &diffEntry{
+				Name:     newChild.Name,
+				IsDir:    true,
+				OldSize:  oldChild.Size,
+				NewSize:  newChild.Size,
+				Children: diffChildren(oldChild.Children, newChild.Children),
+			}
+			entry.Status = dirStatus(entry.Children)
+			entries = append(entries, entry)
+		default:
+			entries = append(entries, &diffEntry{
+				Name:    newChild.Name,
+				Status:  fileStatus(oldChild, newChild),
+				OldSize: oldChild.Size,
+				NewSize: newChild.Size,
+			})
+		}
+	}
+
+	for _, oldChild := range oldChildren {
+		if !seen[oldChild.Name] {
+			entries = append(entries, markTree(oldChild, diffRemoved))
+		}
+	}
+
+	// Directories first, then by name
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// Create diff entries for a subtree that exists on one side only
+func markTree(node *jsonNode, status diffStatus) *diffEntry {
+	entry := &diffEntry{
+		Name:   node.Name,
+		IsDir:  node.Type == "directory",
+		Status: status,
+	}
+	if status == diffAdded {
+		entry.NewSize = node.Size
+	} else {
+		entry.OldSize = node.Size
+	}
+	for _, child := range node.Children {
+		entry.Children = append(entry.Children, markTree(child, status))
+	}
+	return entry
+}
+
+// Compare two versions of a file. A size change is reported as resized;
+// otherwise the content hash, or the modification time and link target
+// when hashes are missing, decide whether it was modified.
+func fileStatus(oldNode *jsonNode, newNode *jsonNode) diffStatus {
+	if oldNode.Size != newNode.Size {
+		return diffResized
+	}
+	if oldNode.Hash != "" && newNode.Hash != "" {
+		if oldNode.Hash != newNode.Hash {
+			return diffModified
+		}
+		return diffUnchanged
+	}
+	if !oldNode.ModTime.Equal(newNode.ModTime) || oldNode.Target != newNode.Target {
+		return diffModified
+	}
+	return diffUnchanged
+}
+
+// A directory is modified when anything below it changed
+func dirStatus(children []*diffEntry) diffStatus {
+	for _, child := range children {
+		if child.Status != diffUnchanged {
+			return diffModified
+		}
+	}
+	return diffUnchanged
+}
+
+// Render the diff tree with a marker and color for each change
+func renderDiff(w io.Writer, root *diffEntry, config Config, treeChars TreeChars, showUnchanged bool) {
+	fmt.Fprintf(w, "%s\n", root.Name)
+	renderDiffChildren(w, root, "", config, treeChars, showUnchanged)
+
+	counts := make(map[diffStatus]int)
+	countChanges(root, counts)
+	fmt.Fprintf(w, "\n%d added, %d removed, %d modified, %d resized\n",
+		counts[diffAdded], counts[diffRemoved], counts[diffModified], counts[diffResized])
+}
+
+// Render the children of a diff entry
+func renderDiffChildren(w io.Writer, entry *diffEntry, prefix string, config Config, treeChars TreeChars, showUnchanged bool) {
+	var visible []*diffEntry
+	for _, child := range entry.Children {
+		if showUnchanged || child.Status != diffUnchanged {
+			visible = append(visible, child)
+		}
+	}
+
+	for i, child := range visible {
+		newPrefix, _ := writeBranch(w, treeChars, i == len(visible)-1, prefix)
+
+		line := diffMarker(child.Status) + " " + child.Name + diffSizeNote(child)
+		if config.Color && child.Status != diffUnchanged {
+			diffColor(child.Status).Fprintf(w, "%s\n", line)
+		} else {
+			fmt.Fprintf(w, "%s\n", line)
+		}
+
+		renderDiffChildren(w, child, newPrefix, config, treeChars, showUnchanged)
+	}
+}
+
+// Get the marker shown before a changed entry
+func diffMarker(status diffStatus) string {
+	switch status {
+	case diffAdded:
+		return "+"
+	case diffRemoved:
+		return "-"
+	case diffModified:
+		return "~"
+	case diffResized:
+		return "*"
+	default:
+		return " "
+	}
+}
+
+// Get the color of a changed entry
+func diffColor(status diffStatus) *color.Color {
+	switch status {
+	case diffAdded:
+		return newColor(color.FgGreen)
+	case diffRemoved:
+		return newColor(color.FgRed)
+	case diffResized:
+		return newColor(color.FgCyan)
+	default:
+		return newColor(color.FgYellow)
+	}
+}
+
+// Describe the size change of a file
+func diffSizeNote(entry *diffEntry) string {
+	if entry.IsDir {
+		return ""
+	}
+	switch entry.Status {
+	case diffAdded:
+		return fmt.Sprintf(" (%s)", formatSize(entry.NewSize))
+	case diffRemoved:
+		return fmt.Sprintf(" (%s)", formatSize(entry.OldSize))
+	case diffResized:
+		return fmt.Sprintf(" (%s -> %s)", formatSize(entry.OldSize), formatSize(entry.NewSize))
+	}
+	return ""
+}
+
+// Count changed files, and directories that were added or removed
+func countChanges(entry *diffEntry, counts map[diffStatus]int) {
+	for _, child := range entry.Children {
+		if !child.IsDir || child.Status == diffAdded || child.Status == diffRemoved {
+			counts[child.Status]++
+		}
+		countChanges(child, counts)
+	}
+}
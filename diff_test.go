@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new jsonNode
+		expected diffStatus
+	}{
+		{"same", jsonNode{Size: 4, Hash: "a"}, jsonNode{Size: 4, Hash: "a"}, diffUnchanged},
+		{"resized", jsonNode{Size: 4, Hash: "a"}, jsonNode{Size: 5, Hash: "b"}, diffResized},
+		{"modified", jsonNode{Size: 4, Hash: "a"}, jsonNode{Size: 4, Hash: "b"}, diffModified},
+		{"retargeted", jsonNode{Target: "a"}, jsonNode{Target: "b"}, diffModified},
+	}
+
+	for _, test := range tests {
+		result := fileStatus(&test.old, &test.new)
+		if result != test.expected {
+			t.Errorf("fileStatus(%q): expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestDiffTrees(t *testing.T) {
+	file := func(name string, size int64, hash string) *jsonNode {
+		return &jsonNode{Name: name, Type: "file", Size: size, Hash: hash}
+	}
+	dir := func(name string, children ...*jsonNode) *jsonNode {
+		return &jsonNode{Name: name, Type: "directory", Children: children}
+	}
+
+	oldRoot := dir("dist",
+		dir("assets", file("app.css", 10, "a"), file("logo.png", 20, "b")),
+		file("app.js", 40, "c"),
+		file("legacy.js", 8, "d"),
+		file("lib", 5, "e"),
+	)
+	newRoot := dir("dist",
+		dir("assets", file("app.css", 10, "x"), file("logo.png", 20, "b")),
+		file("app.js", 44, "y"),
+		file("vendor.js", 120, "z"),
+		dir("lib", file("index.js", 5, "e")),
+	)
+
+	var buf bytes.Buffer
+	renderDiff(&buf, diffTrees(oldRoot, newRoot), Config{}, getTreeChars(false, false), false)
+
+	expected := `dist
++-- ~ assets
+|   ` + "`" + `-- ~ app.css
++-- + lib
+|   ` + "`" + `-- + index.js (5 B)
++-- * app.js (40 B -> 44 B)
++-- - legacy.js (8 B)
++-- - lib (5 B)
+` + "`" + `-- + vendor.js (120 B)
+
+3 added, 2 removed, 1 modified, 1 resized
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected diff output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestRunDiffExitCode(t *testing.T) {
+	tempDir := createTestTree(t)
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if code := runSnapshot([]string{"--path", tempDir, "--out", path}); code != 0 {
+		t.Fatalf("runSnapshot returned %d", code)
+	}
+
+	if code := runDiff([]string{"--color=false", path, tempDir}); code != 0 {
+		t.Errorf("Expected exit code 0 for an unchanged tree, got %d", code)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "new.txt"), []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if code := runDiff([]string{"--color=false", path, tempDir}); code != 1 {
+		t.Errorf("Expected exit code 1 for a changed tree, got %d", code)
+	}
+
+	if code := runDiff([]string{path}); code != 2 {
+		t.Errorf("Expected exit code 2 for a missing argument, got %d", code)
+	}
+}
+
+func TestRunDiffConfigFile(t *testing.T) {
+	parent := createConfigDir(t, ".hyperion.yaml", "exclude: [\"*.log\"]\n")
+	files := []string{"old/a.txt", "new/a.txt", "new/b.log"}
+	for _, file := range files {
+		path := filepath.Join(parent, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The configuration next to the trees excludes the log file
+	oldDir, newDir := filepath.Join(parent, "old"), filepath.Join(parent, "new")
+	if code := runDiff([]string{"--color=false", oldDir, newDir}); code != 0 {
+		t.Errorf("Expected exit code 0 with the configuration of the trees, got %d", code)
+	}
+}
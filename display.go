@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// Valid --color and --charset modes
+const (
+	modeAuto    = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+	charUnicode = "unicode"
+	charASCII   = "ascii"
+)
+
+// Valid --charset modes
+var charsetModes = []string{modeAuto, charUnicode, charASCII}
+
+// colorFlag is the value of --color. It can be used as a boolean flag, so
+// --color and --color=false still mean always and never.
+type colorFlag struct {
+	mode *string
+}
+
+func (c colorFlag) String() string {
+	if c.mode == nil {
+		return ""
+	}
+	return *c.mode
+}
+
+func (c colorFlag) Set(value string) error {
+	switch strings.ToLower(value) {
+	case modeAuto:
+		*c.mode = modeAuto
+	case colorAlways, "true", "1", "yes":
+		*c.mode = colorAlways
+	case colorNever, "false", "0", "no":
+		*c.mode = colorNever
+	default:
+		return fmt.Errorf("expected auto, always or never")
+	}
+	return nil
+}
+
+func (c colorFlag) IsBoolFlag() bool {
+	return true
+}
+
+// Decide whether to use colors. In auto mode colors follow the NO_COLOR
+// and CLICOLOR_FORCE conventions, then whether the output is a terminal.
+func useColor(mode string, terminal bool) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return terminal
+}
+
+// Create a color that follows --color, through the NoColor setting of the
+// color package. The package would otherwise disable every color made while
+// NO_COLOR is set, even with --color=always.
+func newColor(attrs ...color.Attribute) *color.Color {
+	c := color.New(attrs...)
+	if !color.NoColor {
+		c.EnableColor()
+	}
+	return c
+}
+
+// Decide whether to draw the tree with Unicode characters. In auto mode the
+// locale decides, then the terminal.
+func useUnicode(charset string) bool {
+	switch charset {
+	case charUnicode:
+		return true
+	case charASCII:
+		return false
+	}
+
+	// The first locale variable set wins, as in the C library
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			return isUTF8Locale(locale)
+		}
+	}
+
+	// Windows Terminal handles Unicode, the legacy console may not
+	if runtime.GOOS == "windows" {
+		return os.Getenv("WT_SESSION") != ""
+	}
+	return isTerminalSupportsUnicode()
+}
+
+// Check whether a locale such as "en_US.UTF-8" uses the UTF-8 encoding
+func isUTF8Locale(locale string) bool {
+	locale = strings.ToLower(locale)
+	return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+}
+
+// Check whether standard output is a terminal
+func isStdoutTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// Check whether a --charset mode is valid
+func isValidCharset(charset string) bool {
+	for _, c := range charsetModes {
+		if c == charset {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestColorFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, modeAuto},
+		{[]string{"--color"}, colorAlways},
+		{[]string{"--color=false"}, colorNever},
+		{[]string{"--color=true"}, colorAlways},
+		{[]string{"--color=never"}, colorNever},
+		{[]string{"--color=Always"}, colorAlways},
+		{[]string{"--color=auto"}, modeAuto},
+	}
+
+	for _, test := range tests {
+		fs := flag.NewFlagSet("hyperion", flag.ContinueOnError)
+		flags := registerConfigFlags(fs)
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatalf("Parse(%v) failed: %v", test.args, err)
+		}
+		if flags.color != test.expected {
+			t.Errorf("--color with %v: expected %q, got %q", test.args, test.expected, flags.color)
+		}
+	}
+
+	fs := flag.NewFlagSet("hyperion", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	registerConfigFlags(fs)
+	if err := fs.Parse([]string{"--color=sometimes"}); err == nil {
+		t.Error("Expected an error for --color=sometimes")
+	}
+
+	// A mode after a space is left over as an argument, which is rejected
+	fs = flag.NewFlagSet("hyperion", flag.ContinueOnError)
+	flags := registerConfigFlags(fs)
+	if err := fs.Parse([]string{"--color", "never", "--show-files"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := flags.checkNoArgs(); err == nil || !strings.Contains(err.Error(), "--color=never") {
+		t.Errorf("checkNoArgs: expected an error suggesting --color=never, got %v", err)
+	}
+}
+
+func TestColorAlwaysWithNoColor(t *testing.T) {
+	dir := createConfigDir(t, "", "")
+	t.Setenv("NO_COLOR", "1")
+	config, err := resolveArgs(t, "--path", dir, "--color=always")
+	if err != nil {
+		t.Fatalf("resolve: unexpected error: %v", err)
+	}
+
+	// As in main, the color package follows --color
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = !config.Color
+
+	node := &Node{Name: "src", Mode: fs.ModeDir}
+	if output := darkTheme().color(node, false).Sprint(node.Name); !strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected colored output with NO_COLOR and --color=always, got %q", output)
+	}
+	if output := newColor(color.FgRed).Sprint("error"); !strings.Contains(output, "\x1b[31m") {
+		t.Errorf("Expected red output with NO_COLOR and --color=always, got %q", output)
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	tests := []struct {
+		mode     string
+		terminal bool
+		noColor  string
+		force    string
+		term     string
+		expected bool
+	}{
+		{modeAuto, true, "", "", "xterm", true},
+		{modeAuto, false, "", "", "xterm", false},
+		{modeAuto, true, "1", "", "xterm", false},
+		{modeAuto, false, "", "1", "xterm", true},
+		{modeAuto, false, "", "0", "xterm", false},
+		{modeAuto, true, "1", "1", "xterm", false},
+		{modeAuto, true, "", "", "dumb", false},
+		{colorAlways, false, "1", "", "dumb", true},
+		{colorNever, true, "", "1", "xterm", false},
+	}
+
+	for _, test := range tests {
+		t.Setenv("NO_COLOR", test.noColor)
+		t.Setenv("CLICOLOR_FORCE", test.force)
+		t.Setenv("TERM", test.term)
+		if result := useColor(test.mode, test.terminal); result != test.expected {
+			t.Errorf("useColor(%q, %v) with NO_COLOR=%q CLICOLOR_FORCE=%q TERM=%q: expected %v, got %v",
+				test.mode, test.terminal, test.noColor, test.force, test.term, test.expected, result)
+		}
+	}
+}
+
+func TestUseUnicode(t *testing.T) {
+	tests := []struct {
+		charset  string
+		lcAll    string
+		lang     string
+		expected bool
+	}{
+		{charUnicode, "C", "C", true},
+		{charASCII, "", "en_US.UTF-8", false},
+		{modeAuto, "", "en_US.UTF-8", true},
+		{modeAuto, "", "de_DE.utf8", true},
+		{modeAuto, "", "C", false},
+		{modeAuto, "", "en_US.ISO-8859-1", false},
+		{modeAuto, "POSIX", "en_US.UTF-8", false},
+		{modeAuto, "C.UTF-8", "C", true},
+	}
+
+	for _, test := range tests {
+		t.Setenv("LC_ALL", test.lcAll)
+		t.Setenv("LC_CTYPE", "")
+		t.Setenv("LANG", test.lang)
+		if result := useUnicode(test.charset); result != test.expected {
+			t.Errorf("useUnicode(%q) with LC_ALL=%q LANG=%q: expected %v, got %v",
+				test.charset, test.lcAll, test.lang, test.expected, result)
+		}
+	}
+}
+
+func TestResolveCharset(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_CTYPE", "")
+	t.Setenv("LANG", "C")
+
+	tests := []struct {
+		args     []string
+		expected bool
+	}{
+		{nil, false},
+		{[]string{"--unicode"}, true},
+		{[]string{"--unicode=false", "--charset=unicode"}, true},
+		{[]string{"--charset=ascii"}, false},
+	}
+
+	for _, test := range tests {
+		config, err := resolveArgs(t, test.args...)
+		if err != nil {
+			t.Fatalf("resolve(%v) failed: %v", test.args, err)
+		}
+		if config.Unicode != test.expected {
+			t.Errorf("resolve(%v): expected Unicode %v, got %v", test.args, test.expected, config.Unicode)
+		}
+	}
+
+	if _, err := resolveArgs(t, "--charset=ebcdic"); err == nil {
+		t.Error("Expected an error for --charset=ebcdic")
+	}
+}
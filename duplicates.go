@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Files larger than this are compared by a hash of their first bytes
+// before the full content is hashed
+const partialHashSize = 64 * 1024
+
+// duplicateGroup is a set of files with identical content
+type duplicateGroup struct {
+	Size  int64    `json:"size"`
+	Hash  string   `json:"sha256"`
+	Paths []string `json:"paths"`
+}
+
+// Get the bytes that could be freed by keeping only one copy
+func (g duplicateGroup) wasted() int64 {
+	return g.Size * int64(len(g.Paths)-1)
+}
+
+// duplicateReport is the JSON document for --find-duplicates
+type duplicateReport struct {
+	Groups []duplicateGroup `json:"groups"`
+	Wasted int64            `json:"wasted"`
+}
+
+// Find files with identical content. Files are grouped by size, then by
+// a partial hash for large files and finally by a full hash, so only files
+// that could be duplicates are read. Empty files and hard links to the
+// same inode are not reported.
+func findDuplicates(root *Node, jobs int) []duplicateGroup {
+	bySize := make(map[int64][]*Node)
+	collectFiles(root, bySize)
+
+	var groups []duplicateGroup
+	for size, files := range bySize {
+		files = uniqueInodes(files)
+		if len(files) < 2 {
+			continue
+		}
+
+		candidates := map[string][]*Node{"": files}
+		if size > partialHashSize {
+			candidates = groupByHash(files, func(path string) (string, error) {
+				return hashFileHead(path, partialHashSize)
+			}, jobs)
+		}
+
+		for _, candidate := range candidates {
+			if len(candidate) < 2 {
+				continue
+			}
+			for hash, same := range groupByHash(candidate, hashFile, jobs) {
+				if len(same) < 2 {
+					continue
+				}
+				group := duplicateGroup{Size: size, Hash: hash}
+				for _, node := range same {
+					group.Paths = append(group.Paths, node.Path)
+				}
+				sort.Strings(group.Paths)
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	// Biggest savings first
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].wasted() != groups[j].wasted() {
+			return groups[i].wasted() > groups[j].wasted()
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups
+}
+
+// Collect the non-empty regular files of a tree by size
+func collectFiles(node *Node, bySize map[int64][]*Node) {
+	if node.Mode.IsRegular() && node.Err == nil && node.Size > 0 {
+		bySize[node.Size] = append(bySize[node.Size], node)
+	}
+	for _, child := range node.Children {
+		collectFiles(child, bySize)
+	}
+}
+
+// Keep one path per inode, so hard links are not reported as duplicates
+func uniqueInodes(files []*Node) []*Node {
+	type inodeKey struct{ dev, inode uint64 }
+	seen := make(map[inodeKey]bool)
+
+	var unique []*Node
+	for _, node := range files {
+		if node.Sys != nil {
+			key := inodeKey{node.Sys.Dev, node.Sys.Inode}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		unique = append(unique, node)
+	}
+	return unique
+}
+
+// Hash files in parallel and group them by hash. Files that cannot be read
+// are left out.
+func groupByHash(files []*Node, hash func(string) (string, error), jobs int) map[string][]*Node {
+	if jobs < 1 {
+		jobs = 1
+	}
+	hashes := make([]string, len(files))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+
+	for i, node := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			hashes[i], _ = hash(path)
+		}(i, node.Path)
+	}
+	wg.Wait()
+
+	groups := make(map[string][]*Node)
+	for i, node := range files {
+		if hashes[i] != "" {
+			groups[hashes[i]] = append(groups[hashes[i]], node)
+		}
+	}
+	return groups
+}
+
+// Compute the SHA-256 hash of the first n bytes of a file
+func hashFileHead(path string, n int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, n); err != nil && err != io.EOF {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Print each group of duplicates and the total wasted space
+func printDuplicates(w io.Writer, groups []duplicateGroup) {
+	if len(groups) == 0 {
+		fmt.Fprintln(w, "No duplicate files found")
+		return
+	}
+
+	var wasted int64
+	for _, group := range groups {
+		fmt.Fprintf(w, "%d copies of %s (%s wasted)\n", len(group.Paths), formatSize(group.Size), formatSize(group.wasted()))
+		for _, path := range group.Paths {
+			fmt.Fprintf(w, "  %s\n", path)
+		}
+		fmt.Fprintln(w)
+		wasted += group.wasted()
+	}
+
+	noun := "groups"
+	if len(groups) == 1 {
+		noun = "group"
+	}
+	fmt.Fprintf(w, "%d %s of duplicates, %s wasted\n", len(groups), noun, formatSize(wasted))
+}
+
+// Write the duplicate groups as a JSON document
+func writeDuplicatesJSON(w io.Writer, groups []duplicateGroup) error {
+	report := duplicateReport{Groups: groups}
+	if report.Groups == nil {
+		report.Groups = []duplicateGroup{}
+	}
+	for _, group := range groups {
+		report.Wasted += group.wasted()
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	tempDir := t.TempDir()
+	large := bytes.Repeat([]byte("0123456789"), partialHashSize/5)
+	changedTail := append([]byte{}, large...)
+	changedTail[len(changedTail)-1] = 'x'
+
+	files := map[string][]byte{
+		"a/copy.txt":      []byte("same content"),
+		"b/copy.txt":      []byte("same content"),
+		"other.txt":       []byte("same length!"),
+		"large.bin":       large,
+		"a/large.bin":     large,
+		"changed.bin":     changedTail,
+		"empty1":          {},
+		"empty2":          {},
+		"unique-size.txt": []byte("unique"),
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+
+	config := testConfig(tempDir)
+	config.StatTable = false
+	stats := newTestStats()
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	groups := findDuplicates(root, 4)
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups of duplicates, got %d: %+v", len(groups), groups)
+	}
+
+	expected := []string{filepath.Join(tempDir, "a/large.bin"), filepath.Join(tempDir, "large.bin")}
+	if !reflect.DeepEqual(groups[0].Paths, expected) {
+		t.Errorf("Expected largest group %v, got %v", expected, groups[0].Paths)
+	}
+	if groups[0].wasted() != int64(len(large)) {
+		t.Errorf("Expected %d wasted bytes, got %d", len(large), groups[0].wasted())
+	}
+
+	expected = []string{filepath.Join(tempDir, "a/copy.txt"), filepath.Join(tempDir, "b/copy.txt")}
+	if !reflect.DeepEqual(groups[1].Paths, expected) {
+		t.Errorf("Expected second group %v, got %v", expected, groups[1].Paths)
+	}
+}
+
+func TestFindDuplicatesHardLinks(t *testing.T) {
+	tempDir := t.TempDir()
+	original := filepath.Join(tempDir, "original.txt")
+	if err := os.WriteFile(original, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.Link(original, filepath.Join(tempDir, "link.txt")); err != nil {
+		t.Skipf("Hard links not supported: %v", err)
+	}
+
+	stats := newTestStats()
+	root, err := scanTree(testConfig(tempDir), &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	if groups := findDuplicates(root, 1); len(groups) != 0 {
+		t.Errorf("Expected hard links not to be reported, got %+v", groups)
+	}
+}
+
+func TestPrintDuplicates(t *testing.T) {
+	var buf bytes.Buffer
+	printDuplicates(&buf, []duplicateGroup{{Size: 1024, Paths: []string{"a", "b", "c"}}})
+
+	expected := "3 copies of 1.0 KB (2.0 KB wasted)\n  a\n  b\n  c\n\n1 group of duplicates, 2.0 KB wasted\n"
+	if buf.String() != expected {
+		t.Errorf("printDuplicates: expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	printDuplicates(&buf, nil)
+	if !strings.HasPrefix(buf.String(), "No duplicate files found") {
+		t.Errorf("printDuplicates(nil): unexpected output %q", buf.String())
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// Exit codes of the main command and the snapshot subcommand
+const (
+	exitFailure    = 1 // The output could not be written, or git failed
+	exitUsage      = 2 // Invalid flags or configuration
+	exitPathError  = 3 // The path to scan does not exist or cannot be read
+	exitUnreadable = 4 // Entries could not be read, with --strict
+)
+
+// ScanError is an entry that could not be read during the scan
+type ScanError struct {
+	Path  string
+	Error string
+}
+
+// Collect the errors of a tree, sorted by path
+func collectErrors(root *Node) []ScanError {
+	var scanErrors []ScanError
+	var collect func(node *Node)
+	collect = func(node *Node) {
+		if node.Err != nil {
+			scanErrors = append(scanErrors, ScanError{Path: node.Path, Error: errorText(node.Err)})
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	collect(root)
+
+	sort.Slice(scanErrors, func(i, j int) bool {
+		return scanErrors[i].Path < scanErrors[j].Path
+	})
+	return scanErrors
+}
+
+// Get the message of an error without the path it carries, which is
+// already shown next to the entry
+func errorText(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// Write the entries that could not be read after the output
+func writeErrorSummary(w io.Writer, scanErrors []ScanError) {
+	if len(scanErrors) == 0 {
+		return
+	}
+
+	if len(scanErrors) == 1 {
+		fmt.Fprintln(w, "\n1 entry could not be read:")
+	} else {
+		fmt.Fprintf(w, "\n%d entries could not be read:\n", len(scanErrors))
+	}
+	for _, scanErr := range scanErrors {
+		fmt.Fprintf(w, "  %s: %s\n", scanErr.Path, scanErr.Error)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+// Create a tree with an unreadable directory and an unreadable file
+func createErrorTree() *Node {
+	return &Node{Name: "root", Path: "root", Mode: fs.ModeDir, Children: []*Node{
+		{Name: "locked", Path: "root/locked", Mode: fs.ModeDir,
+			Err: &fs.PathError{Op: "open", Path: "root/locked", Err: fs.ErrPermission}},
+		{Name: "src", Path: "root/src", Mode: fs.ModeDir, Children: []*Node{
+			{Name: "gone.txt", Path: "root/src/gone.txt", Err: &fs.PathError{Op: "lstat", Path: "root/src/gone.txt", Err: fs.ErrNotExist}},
+			{Name: "main.go", Path: "root/src/main.go"},
+		}},
+	}}
+}
+
+func TestErrorText(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&fs.PathError{Op: "open", Path: "/secret", Err: fs.ErrPermission}, "permission denied"},
+		{errors.New("symlink loop"), "symlink loop"},
+	}
+
+	for _, test := range tests {
+		if result := errorText(test.err); result != test.expected {
+			t.Errorf("errorText(%v): expected %q, got %q", test.err, test.expected, result)
+		}
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	scanErrors := collectErrors(createErrorTree())
+
+	expected := []ScanError{
+		{Path: "root/locked", Error: "permission denied"},
+		{Path: "root/src/gone.txt", Error: "file does not exist"},
+	}
+	if len(scanErrors) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), scanErrors)
+	}
+	for i := range expected {
+		if scanErrors[i] != expected[i] {
+			t.Errorf("Error %d: expected %+v, got %+v", i, expected[i], scanErrors[i])
+		}
+	}
+}
+
+func TestWriteErrorSummary(t *testing.T) {
+	var buf bytes.Buffer
+	writeErrorSummary(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no summary without errors, got %q", buf.String())
+	}
+
+	writeErrorSummary(&buf, collectErrors(createErrorTree()))
+	expected := "\n2 entries could not be read:\n" +
+		"  root/locked: permission denied\n" +
+		"  root/src/gone.txt: file does not exist\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected summary:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestRenderErrorsInline(t *testing.T) {
+	root := createErrorTree()
+
+	var buf bytes.Buffer
+	renderer := NewBasicRenderer(&buf, Config{}, getTreeChars(false, false))
+	renderer.RenderRoot(root)
+	walkDir(renderer, root, "")
+
+	expected := "root\n" +
+		"+-- locked [error: permission denied]\n" +
+		"`-- src\n" +
+		"    +-- gone.txt [error: file does not exist]\n" +
+		"    `-- main.go\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected tree output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File categories, the coarse groups of --group-by category
+const (
+	categorySource   = "source"
+	categoryDocument = "document"
+	categoryImage    = "image"
+	categoryMedia    = "media"
+	categoryArchive  = "archive"
+	categoryBinary   = "binary"
+	categoryData     = "data"
+	categoryOther    = "other"
+)
+
+// Valid --group-by modes
+var groupModes = []string{"ext", "language", "category"}
+
+// FileType is the language or format of a file and its category
+type FileType struct {
+	Language string
+	Category string
+}
+
+// Number of bytes read from a file to sniff its type
+const sniffSize = 512
+
+// File names with a type of their own, matched case-insensitively
+var fileNameTypes = map[string]FileType{
+	"makefile":       {"Makefile", categorySource},
+	"gnumakefile":    {"Makefile", categorySource},
+	"dockerfile":     {"Dockerfile", categorySource},
+	"containerfile":  {"Dockerfile", categorySource},
+	"jenkinsfile":    {"Groovy", categorySource},
+	"vagrantfile":    {"Ruby", categorySource},
+	"gemfile":        {"Ruby", categorySource},
+	"rakefile":       {"Ruby", categorySource},
+	"podfile":        {"Ruby", categorySource},
+	"cmakelists.txt": {"CMake", categorySource},
+	"build.bazel":    {"Starlark", categorySource},
+	"workspace":      {"Starlark", categorySource},
+	"go.mod":         {"Go Module", categoryData},
+	"go.sum":         {"Go Module", categoryData},
+	"gemfile.lock":   {"Lockfile", categoryData},
+	"cargo.lock":     {"Lockfile", categoryData},
+	"yarn.lock":      {"Lockfile", categoryData},
+	"license":        {"Text", categoryDocument},
+	"copying":        {"Text", categoryDocument},
+	"authors":        {"Text", categoryDocument},
+	"readme":         {"Text", categoryDocument},
+	"changelog":      {"Text", categoryDocument},
+	".gitignore":     {"Ignore List", categoryData},
+	".dockerignore":  {"Ignore List", categoryData},
+	".gitattributes": {"Git Config", categoryData},
+	".gitmodules":    {"Git Config", categoryData},
+	".editorconfig":  {"INI", categoryData},
+	".bashrc":        {"Shell", categorySource},
+	".bash_profile":  {"Shell", categorySource},
+	".profile":       {"Shell", categorySource},
+	".zshrc":         {"Shell", categorySource},
+}
+
+// Extensions made of several parts, checked before the last part alone
+var multiExtTypes = map[string]FileType{
+	".tar.gz":  {"Tar", categoryArchive},
+	".tar.bz2": {"Tar", categoryArchive},
+	".tar.xz":  {"Tar", categoryArchive},
+	".tar.zst": {"Tar", categoryArchive},
+	".tar.lz":  {"Tar", categoryArchive},
+	".d.ts":    {"TypeScript", categorySource},
+	".min.js":  {"JavaScript", categorySource},
+	".min.css": {"CSS", categorySource},
+}
+
+// Types of the common file extensions
+var extTypes = map[string]FileType{
+	".go":     {"Go", categorySource},
+	".c":      {"C", categorySource},
+	".h":      {"C", categorySource},
+	".cc":     {"C++", categorySource},
+	".cpp":    {"C++", categorySource},
+	".cxx":    {"C++", categorySource},
+	".hpp":    {"C++", categorySource},
+	".cs":     {"C#", categorySource},
+	".java":   {"Java", categorySource},
+	".kt":     {"Kotlin", categorySource},
+	".scala":  {"Scala", categorySource},
+	".groovy": {"Groovy", categorySource},
+	".rs":     {"Rust", categorySource},
+	".swift":  {"Swift", categorySource},
+	".m":      {"Objective-C", categorySource},
+	".py":     {"Python", categorySource},
+	".rb":     {"Ruby", categorySource},
+	".php":    {"PHP", categorySource},
+	".pl":     {"Perl", categorySource},
+	".lua":    {"Lua", categorySource},
+	".js":     {"JavaScript", categorySource},
+	".mjs":    {"JavaScript", categorySource},
+	".cjs":    {"JavaScript", categorySource},
+	".jsx":    {"JavaScript", categorySource},
+	".ts":     {"TypeScript", categorySource},
+	".tsx":    {"TypeScript", categorySource},
+	".vue":    {"Vue", categorySource},
+	".html":   {"HTML", categorySource},
+	".htm":    {"HTML", categorySource},
+	".css":    {"CSS", categorySource},
+	".scss":   {"CSS", categorySource},
+	".sh":     {"Shell", categorySource},
+	".bash":   {"Shell", categorySource},
+	".zsh":    {"Shell", categorySource},
+	".fish":   {"Shell", categorySource},
+	".ps1":    {"PowerShell", categorySource},
+	".bat":    {"Batch", categorySource},
+	".sql":    {"SQL", categorySource},
+	".proto":  {"Protocol Buffers", categorySource},
+	".cmake":  {"CMake", categorySource},
+	".mk":     {"Makefile", categorySource},
+	".md":     {"Markdown", categoryDocument},
+	".rst":    {"reStructuredText", categoryDocument},
+	".txt":    {"Text", categoryDocument},
+	".pdf":    {"PDF", categoryDocument},
+	".doc":    {"Word", categoryDocument},
+	".docx":   {"Word", categoryDocument},
+	".odt":    {"OpenDocument", categoryDocument},
+	".rtf":    {"RTF", categoryDocument},
+	".tex":    {"TeX", categoryDocument},
+	".xls":    {"Spreadsheet", categoryDocument},
+	".xlsx":   {"Spreadsheet", categoryDocument},
+	".ppt":    {"Presentation", categoryDocument},
+	".pptx":   {"Presentation", categoryDocument},
+	".json":   {"JSON", categoryData},
+	".yaml":   {"YAML", categoryData},
+	".yml":    {"YAML", categoryData},
+	".toml":   {"TOML", categoryData},
+	".xml":    {"XML", categoryData},
+	".csv":    {"CSV", categoryData},
+	".ini":    {"INI", categoryData},
+	".conf":   {"Config", categoryData},
+	".env":    {"Config", categoryData},
+	".lock":   {"Lockfile", categoryData},
+	".db":     {"Database", categoryData},
+	".sqlite": {"SQLite", categoryData},
+	".png":    {"PNG", categoryImage},
+	".jpg":    {"JPEG", categoryImage},
+	".jpeg":   {"JPEG", categoryImage},
+	".gif":    {"GIF", categoryImage},
+	".bmp":    {"BMP", categoryImage},
+	".webp":   {"WebP", categoryImage},
+	".svg":    {"SVG", categoryImage},
+	".ico":    {"Icon", categoryImage},
+	".tif":    {"TIFF", categoryImage},
+	".tiff":   {"TIFF", categoryImage},
+	".psd":    {"Photoshop", categoryImage},
+	".mp3":    {"MP3", categoryMedia},
+	".wav":    {"WAV", categoryMedia},
+	".flac":   {"FLAC", categoryMedia},
+	".ogg":    {"Ogg", categoryMedia},
+	".mp4":    {"MP4", categoryMedia},
+	".mkv":    {"Matroska", categoryMedia},
+	".mov":    {"QuickTime", categoryMedia},
+	".avi":    {"AVI", categoryMedia},
+	".webm":   {"WebM", categoryMedia},
+	".zip":    {"Zip", categoryArchive},
+	".tar":    {"Tar", categoryArchive},
+	".gz":     {"Gzip", categoryArchive},
+	".tgz":    {"Tar", categoryArchive},
+	".bz2":    {"Bzip2", categoryArchive},
+	".xz":     {"XZ", categoryArchive},
+	".zst":    {"Zstandard", categoryArchive},
+	".7z":     {"7-Zip", categoryArchive},
+	".rar":    {"RAR", categoryArchive},
+	".jar":    {"Java Archive", categoryArchive},
+	".deb":    {"Debian Package", categoryArchive},
+	".rpm":    {"RPM Package", categoryArchive},
+	".iso":    {"Disk Image", categoryArchive},
+	".dmg":    {"Disk Image", categoryArchive},
+	".exe":    {"Executable", categoryBinary},
+	".dll":    {"Library", categoryBinary},
+	".so":     {"Library", categoryBinary},
+	".dylib":  {"Library", categoryBinary},
+	".a":      {"Library", categoryBinary},
+	".o":      {"Object", categoryBinary},
+	".class":  {"Java Class", categoryBinary},
+	".pyc":    {"Python Bytecode", categoryBinary},
+	".wasm":   {"WebAssembly", categoryBinary},
+	".bin":    {"Binary", categoryBinary},
+	".ttf":    {"Font", categoryBinary},
+	".otf":    {"Font", categoryBinary},
+	".woff":   {"Font", categoryBinary},
+	".woff2":  {"Font", categoryBinary},
+}
+
+// Leading bytes of the common binary formats
+var magicTypes = []struct {
+	magic    string
+	fileType FileType
+}{
+	{"\x7fELF", FileType{"ELF", categoryBinary}},
+	{"\xfe\xed\xfa\xce", FileType{"Mach-O", categoryBinary}},
+	{"\xfe\xed\xfa\xcf", FileType{"Mach-O", categoryBinary}},
+	{"\xce\xfa\xed\xfe", FileType{"Mach-O", categoryBinary}},
+	{"\xcf\xfa\xed\xfe", FileType{"Mach-O", categoryBinary}},
+	{"\xca\xfe\xba\xbe", FileType{"Mach-O", categoryBinary}},
+	{"MZ", FileType{"Executable", categoryBinary}},
+	{"\x00asm", FileType{"WebAssembly", categoryBinary}},
+	{"\x89PNG\r\n\x1a\n", FileType{"PNG", categoryImage}},
+	{"\xff\xd8\xff", FileType{"JPEG", categoryImage}},
+	{"GIF87a", FileType{"GIF", categoryImage}},
+	{"GIF89a", FileType{"GIF", categoryImage}},
+	{"%PDF-", FileType{"PDF", categoryDocument}},
+	{"PK\x03\x04", FileType{"Zip", categoryArchive}},
+	{"\x1f\x8b", FileType{"Gzip", categoryArchive}},
+	{"BZh", FileType{"Bzip2", categoryArchive}},
+	{"\xfd7zXZ\x00", FileType{"XZ", categoryArchive}},
+	{"\x28\xb5\x2f\xfd", FileType{"Zstandard", categoryArchive}},
+	{"7z\xbc\xaf\x27\x1c", FileType{"7-Zip", categoryArchive}},
+	{"Rar!\x1a\x07", FileType{"RAR", categoryArchive}},
+	{"SQLite format 3\x00", FileType{"SQLite", categoryData}},
+	{"ID3", FileType{"MP3", categoryMedia}},
+	{"OggS", FileType{"Ogg", categoryMedia}},
+	{"fLaC", FileType{"FLAC", categoryMedia}},
+}
+
+// Languages of the interpreters named in shebang lines
+var interpreterTypes = map[string]FileType{
+	"sh":      {"Shell", categorySource},
+	"bash":    {"Shell", categorySource},
+	"zsh":     {"Shell", categorySource},
+	"dash":    {"Shell", categorySource},
+	"ksh":     {"Shell", categorySource},
+	"fish":    {"Shell", categorySource},
+	"python":  {"Python", categorySource},
+	"ruby":    {"Ruby", categorySource},
+	"perl":    {"Perl", categorySource},
+	"php":     {"PHP", categorySource},
+	"node":    {"JavaScript", categorySource},
+	"deno":    {"TypeScript", categorySource},
+	"lua":     {"Lua", categorySource},
+	"awk":     {"Awk", categorySource},
+	"make":    {"Makefile", categorySource},
+	"pwsh":    {"PowerShell", categorySource},
+	"tclsh":   {"Tcl", categorySource},
+	"Rscript": {"R", categorySource},
+}
+
+// Types of files that could not be classified otherwise
+var (
+	textType    = FileType{"Text", categoryDocument}
+	binaryType  = FileType{"Binary", categoryBinary}
+	unknownType = FileType{"Unknown", categoryOther}
+)
+
+// Check whether a --group-by mode is valid
+func isValidGroupMode(mode string) bool {
+	for _, m := range groupModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether the languages or categories of files are shown, by
+// --group-by, by the colors of the theme on listed files or in JSON and HTML
+// output. Only then are files with unknown names read to classify them.
+func usesFileTypes(config Config) bool {
+	if config.GroupBy == "language" || config.GroupBy == "category" {
+		return true
+	}
+	listed := config.ShowFiles || config.Interactive
+	if listed && config.Color && (config.Theme == nil || len(config.Theme.Categories) > 0) {
+		return true
+	}
+	return config.Output == "json" || config.Output == "html"
+}
+
+// Classify a file by its name, then by its content when the name says
+// nothing. Only regular files are read.
+func classifyFile(name, path string, mode fs.FileMode) FileType {
+	if fileType, ok := classifyName(name); ok {
+		return fileType
+	}
+	if !mode.IsRegular() {
+		return unknownType
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return unknownType
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return unknownType
+	}
+	return sniffContent(head[:n])
+}
+
+// Classify a file by its name, its multi-part extension or its extension
+func classifyName(name string) (FileType, bool) {
+	lower := strings.ToLower(name)
+	if fileType, ok := fileNameTypes[lower]; ok {
+		return fileType, true
+	}
+	if fileType, ok := multiExtTypes[multiPartExtension(lower)]; ok {
+		return fileType, true
+	}
+	if fileType, ok := extTypes[getFileExtension(lower)]; ok {
+		return fileType, true
+	}
+	return FileType{}, false
+}
+
+// Get the known multi-part extension of a lowercase name, such as
+// ".tar.gz", or "" if it has none
+func multiPartExtension(lower string) string {
+	for ext := range multiExtTypes {
+		if strings.HasSuffix(lower, ext) && len(lower) > len(ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// Classify the first bytes of a file by magic numbers and shebang lines.
+// Content with a NUL byte is binary, anything else is text.
+func sniffContent(head []byte) FileType {
+	if len(head) == 0 {
+		return unknownType
+	}
+	for _, m := range magicTypes {
+		if bytes.HasPrefix(head, []byte(m.magic)) {
+			return m.fileType
+		}
+	}
+	if bytes.HasPrefix(head, []byte("#!")) {
+		if fileType, ok := interpreterTypes[shebangInterpreter(head)]; ok {
+			return fileType
+		}
+		return FileType{"Script", categorySource}
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return binaryType
+	}
+	return textType
+}
+
+// Get the interpreter named by a shebang line, such as "python" for
+// "#!/usr/bin/env python3" or "sh" for "#!/bin/sh -e"
+func shebangInterpreter(head []byte) string {
+	line := head[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Skip the options of env, such as -S
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	// python3 and python3.12 are python
+	return strings.TrimRight(interpreter, "0123456789.")
+}
+
+// Get the group of a file for the type statistics: its extension, its
+// language or its category
+func fileGroup(node *Node, mode string) string {
+	switch mode {
+	case "language":
+		return node.FileType.Language
+	case "category":
+		return node.FileType.Category
+	default:
+		if ext := multiPartExtension(strings.ToLower(node.Name)); ext != "" {
+			return ext
+		}
+		return getFileExtension(node.Name)
+	}
+}
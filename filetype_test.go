@@ -0,0 +1,214 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected FileType
+		ok       bool
+	}{
+		{"Makefile", FileType{"Makefile", categorySource}, true},
+		{"Dockerfile", FileType{"Dockerfile", categorySource}, true},
+		{"CMakeLists.txt", FileType{"CMake", categorySource}, true},
+		{"main.go", FileType{"Go", categorySource}, true},
+		{"README.MD", FileType{"Markdown", categoryDocument}, true},
+		{"backup.tar.gz", FileType{"Tar", categoryArchive}, true},
+		{"notes.gz", FileType{"Gzip", categoryArchive}, true},
+		{"index.d.ts", FileType{"TypeScript", categorySource}, true},
+		{"logo.png", FileType{"PNG", categoryImage}, true},
+		{".gitignore", FileType{"Ignore List", categoryData}, true},
+		{"run", FileType{}, false},
+		{"data.xyz", FileType{}, false},
+	}
+
+	for _, test := range tests {
+		result, ok := classifyName(test.name)
+		if result != test.expected || ok != test.ok {
+			t.Errorf("classifyName(%q): expected %v %v, got %v %v", test.name, test.expected, test.ok, result, ok)
+		}
+	}
+}
+
+func TestSniffContent(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected FileType
+	}{
+		{"\x7fELF\x02\x01\x01", FileType{"ELF", categoryBinary}},
+		{"\x89PNG\r\n\x1a\n\x00\x00", FileType{"PNG", categoryImage}},
+		{"%PDF-1.7\n", FileType{"PDF", categoryDocument}},
+		{"PK\x03\x04\x14\x00", FileType{"Zip", categoryArchive}},
+		{"#!/bin/sh\necho hi\n", FileType{"Shell", categorySource}},
+		{"#!/usr/bin/env python3\nprint(1)\n", FileType{"Python", categorySource}},
+		{"#!/usr/bin/env -S node --harmony\n", FileType{"JavaScript", categorySource}},
+		{"#!/opt/custom/interp\n", FileType{"Script", categorySource}},
+		{"plain words\n", textType},
+		{"\x01\x02\x00\x03", binaryType},
+		{"", unknownType},
+	}
+
+	for _, test := range tests {
+		if result := sniffContent([]byte(test.content)); result != test.expected {
+			t.Errorf("sniffContent(%q): expected %v, got %v", test.content, test.expected, result)
+		}
+	}
+}
+
+func TestShebangInterpreter(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"#!/bin/bash", "bash"},
+		{"#! /bin/sh -e\n", "sh"},
+		{"#!/usr/bin/python3.12\n", "python"},
+		{"#!/usr/bin/env ruby -w\n", "ruby"},
+		{"#!/usr/bin/env -S deno run\n", "deno"},
+		{"#!\n", ""},
+	}
+
+	for _, test := range tests {
+		if result := shebangInterpreter([]byte(test.line)); result != test.expected {
+			t.Errorf("shebangInterpreter(%q): expected %q, got %q", test.line, test.expected, result)
+		}
+	}
+}
+
+func TestClassifyFile(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"Makefile": "all:\n",
+		"deploy":   "#!/bin/bash\n",
+		"tool":     "\x7fELF\x02",
+		"empty":    "",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		expected FileType
+	}{
+		{"Makefile", FileType{"Makefile", categorySource}},
+		{"deploy", FileType{"Shell", categorySource}},
+		{"tool", FileType{"ELF", categoryBinary}},
+		{"empty", unknownType},
+		{"missing", unknownType},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(tempDir, test.name)
+		if result := classifyFile(test.name, path, 0644); result != test.expected {
+			t.Errorf("classifyFile(%q): expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestUsesFileTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected bool
+	}{
+		{"default", Config{GroupBy: "ext", Output: "text"}, false},
+		{"group by language", Config{GroupBy: "language", Output: "text"}, true},
+		{"group by category", Config{GroupBy: "category", Output: "text"}, true},
+		{"dark theme", Config{GroupBy: "ext", Output: "text", ShowFiles: true, Color: true, Theme: darkTheme()}, true},
+		{"dark theme, interactive", Config{GroupBy: "ext", Output: "text", Interactive: true, Color: true, Theme: darkTheme()}, true},
+		{"dark theme without files", Config{GroupBy: "ext", Output: "text", Color: true, Theme: darkTheme()}, false},
+		{"ls theme", Config{GroupBy: "ext", Output: "text", ShowFiles: true, Color: true, Theme: lsTheme()}, false},
+		{"json", Config{GroupBy: "ext", Output: "json"}, true},
+		{"html", Config{GroupBy: "ext", Output: "html"}, true},
+		{"markdown", Config{GroupBy: "ext", Output: "markdown"}, false},
+	}
+
+	for _, test := range tests {
+		if result := usesFileTypes(test.config); result != test.expected {
+			t.Errorf("usesFileTypes(%s): expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestScanTreeSniff(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "tool"), []byte("\x7fELF\x02"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Contents are only read when the file types are used
+	for _, output := range []string{"text", "json"} {
+		config := testConfig(tempDir)
+		config.Output = output
+		stats := newTestStats()
+		root, err := scanTree(config, &stats)
+		if err != nil {
+			t.Fatalf("scanTree failed: %v", err)
+		}
+
+		expected := unknownType
+		if output == "json" {
+			expected = FileType{"ELF", categoryBinary}
+		}
+		if result := root.Children[0].FileType; result != expected {
+			t.Errorf("scanTree(%q): expected %v, got %v", output, expected, result)
+		}
+	}
+
+	// Colored trees without files never show the types of files
+	config := testConfig(tempDir)
+	config.ShowFiles = false
+	config.Color = true
+	config.Theme = darkTheme()
+	stats := newTestStats()
+	if newScanner(config, &stats).sniff {
+		t.Error("Expected no content sniffing for a colored tree without --show-files")
+	}
+}
+
+func TestFileGroup(t *testing.T) {
+	node := &Node{Name: "Backup.TAR.GZ", FileType: FileType{"Tar", categoryArchive}}
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{"ext", ".tar.gz"},
+		{"", ".tar.gz"},
+		{"language", "Tar"},
+		{"category", categoryArchive},
+	}
+
+	for _, test := range tests {
+		if result := fileGroup(node, test.mode); result != test.expected {
+			t.Errorf("fileGroup(%q): expected %q, got %q", test.mode, test.expected, result)
+		}
+	}
+
+	if result := fileGroup(&Node{Name: "Makefile"}, "ext"); result != "" {
+		t.Errorf("fileGroup(%q): expected no extension, got %q", "Makefile", result)
+	}
+}
+
+func TestScanTreeGroupBy(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	config.GroupBy = "category"
+	stats := newTestStats()
+	if _, err := scanTree(config, &stats); err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	if _, exists := stats.FileTypes[categoryDocument]; !exists {
+		t.Errorf("Expected the .txt files to be grouped as %q, got %v", categoryDocument, stats.FileTypes)
+	}
+	if _, exists := stats.FileTypes[".txt"]; exists {
+		t.Errorf("Expected no extension groups, got %v", stats.FileTypes)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// stringList is a repeatable command line flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends the comma-separated values of one flag occurrence
+func (l *stringList) Set(value string) error {
+	*l = append(*l, splitCommaString(value)...)
+	return nil
+}
+
+// regexList is a repeatable command line flag holding regular expressions.
+// Values are not split on commas, which are common in expressions.
+type regexList []string
+
+func (l *regexList) String() string {
+	return strings.Join(*l, " ")
+}
+
+// Set appends the expression of one flag occurrence
+func (l *regexList) Set(value string) error {
+	if _, err := regexp.Compile(value); err != nil {
+		return err
+	}
+	*l = append(*l, value)
+	return nil
+}
+
+// pathFilter applies the --include and --exclude patterns to paths
+// relative to the scan root. Exclusions prune both files and directories;
+// when include patterns are given, only files matching one of them are kept.
+type pathFilter struct {
+	include   []string
+	exclude   []string
+	includeRe []*regexp.Regexp
+	excludeRe []*regexp.Regexp
+}
+
+// Create a path filter from the configuration
+func newPathFilter(config Config) (*pathFilter, error) {
+	f := &pathFilter{
+		include: config.Include,
+		exclude: config.Exclude,
+	}
+
+	for _, expr := range config.IncludeRegex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include regex %q: %v", expr, err)
+		}
+		f.includeRe = append(f.includeRe, re)
+	}
+
+	for _, expr := range config.ExcludeRegex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude regex %q: %v", expr, err)
+		}
+		f.excludeRe = append(f.excludeRe, re)
+	}
+
+	return f, nil
+}
+
+// Check whether an entry matches an exclude glob or regex
+func (f *pathFilter) excluded(relPath string) bool {
+	if f == nil {
+		return false
+	}
+	return matchAnyGlob(f.exclude, relPath) || matchAnyRegex(f.excludeRe, relPath)
+}
+
+// Check whether a file passes the include globs and regexes
+func (f *pathFilter) included(relPath string) bool {
+	if f == nil || len(f.include) == 0 && len(f.includeRe) == 0 {
+		return true
+	}
+	return matchAnyGlob(f.include, relPath) || matchAnyRegex(f.includeRe, relPath)
+}
+
+// Match a relative path against glob patterns. Patterns without a slash
+// match the entry name at any depth, others match the whole path.
+func matchAnyGlob(patterns []string, relPath string) bool {
+	name := relPath[strings.LastIndex(relPath, "/")+1:]
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			if matchGlob(strings.TrimPrefix(pattern, "/"), relPath) {
+				return true
+			}
+		} else if matchSegment(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match a relative path against regular expressions
+func matchAnyRegex(exprs []*regexp.Regexp, relPath string) bool {
+	for _, re := range exprs {
+		if re.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
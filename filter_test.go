@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestPathFilter(t *testing.T) {
+	config := Config{
+		Include:      []string{"*.go", "docs/**/*.md"},
+		Exclude:      []string{"*_test.go", "build-*", "vendor/**/testdata"},
+		IncludeRegex: []string{`^scripts/.*\.sh$`},
+		ExcludeRegex: []string{`(^|/)tmp\d+/`},
+	}
+
+	filter, err := newPathFilter(config)
+	if err != nil {
+		t.Fatalf("newPathFilter failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		excluded bool
+		included bool
+	}{
+		{"main.go", false, true},
+		{"cmd/tool/main.go", false, true},
+		{"main_test.go", true, true},
+		{"build-linux", true, false},
+		{"src/build-linux", true, false},
+		{"vendor/lib/testdata", true, false},
+		{"docs/guide/intro.md", false, true},
+		{"README.md", false, false},
+		{"scripts/deploy.sh", false, true},
+		{"src/tmp42/file.go", true, true},
+	}
+
+	for _, test := range tests {
+		if result := filter.excluded(test.path); result != test.excluded {
+			t.Errorf("excluded(%q): expected %v, got %v", test.path, test.excluded, result)
+		}
+		if result := filter.included(test.path); result != test.included {
+			t.Errorf("included(%q): expected %v, got %v", test.path, test.included, result)
+		}
+	}
+}
+
+func TestPathFilterInvalidRegex(t *testing.T) {
+	if _, err := newPathFilter(Config{ExcludeRegex: []string{"("}}); err == nil {
+		t.Error("Expected an error for an invalid exclude regex")
+	}
+}
+
+func TestPatternFlags(t *testing.T) {
+	var globs stringList
+	var exprs regexList
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&globs, "include", "")
+	fs.Var(&exprs, "include-regex", "")
+
+	args := []string{"--include", "*.go, *.md", "--include", "Makefile", "--include-regex", `a{1,3}`}
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(globs) != 3 || globs[1] != "*.md" {
+		t.Errorf("Expected 3 globs, got %v", globs)
+	}
+	if len(exprs) != 1 || exprs[0] != `a{1,3}` {
+		t.Errorf("Expected one unsplit regex, got %v", exprs)
+	}
+
+	if err := fs.Parse([]string{"--include-regex", "("}); err == nil {
+		t.Error("Expected an error for an invalid regex flag")
+	}
+}
+
+func TestScanTreeIncludeExclude(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	config.Include = []string{"*.txt"}
+	config.Exclude = []string{"dir1/subdir1"}
+	stats := newTestStats()
+
+	if _, err := scanTree(config, &stats); err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	// file1.txt and dir1/file3.txt remain, dir1/subdir1 is pruned
+	if stats.TotalFiles != 2 {
+		t.Errorf("Expected 2 files, got %d", stats.TotalFiles)
+	}
+	if stats.TotalDirs != 3 {
+		t.Errorf("Expected 3 directories, got %d", stats.TotalDirs)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// configFlags holds the raw values of the scan and display flags until
+// they are processed into a Config. The main command and the subcommands
+// register the same flags on their own flag sets.
+type configFlags struct {
+	fs      *flag.FlagSet
+	config  Config
+	profile string
+	theme   string
+	color   string
+	charset string
+
+	// Flags that environment variables and configuration files can set
+	names []string
+
+	excludeFolders, excludeFiles, excludeNames string
+	include, exclude                           stringList
+	includeRegex, excludeRegex                 regexList
+	dirsFirst, dirsLast, mixed                 bool
+}
+
+// Valid values of --output
+var outputFormats = []string{"text", "json", "html", "markdown"}
+
+// Check whether an output format is supported
+func isValidOutput(output string) bool {
+	for _, format := range outputFormats {
+		if output == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Register the scan and display flags on a flag set
+func registerConfigFlags(fs *flag.FlagSet) *configFlags {
+	f := &configFlags{fs: fs}
+	config := &f.config
+
+	fs.StringVar(&config.Path, "path", ".", "Root directory to scan")
+	fs.StringVar(&f.excludeFolders, "exclude-folders", "node_modules", "Folders to exclude from tree (comma-separated)")
+	fs.BoolVar(&config.ShowFiles, "show-files", false, "Whether to show files in output")
+	fs.StringVar(&f.excludeFiles, "exclude-files", "", "File extensions to exclude (comma-separated, e.g., '.exe,.dll')")
+	fs.StringVar(&f.excludeNames, "exclude-names", "", "File names to exclude exactly (comma-separated, e.g., 'config.json,README.md')")
+	fs.BoolVar(&config.Gitignore, "gitignore", false, "Skip entries ignored by .gitignore files and .git/info/exclude")
+	fs.BoolVar(&config.ShowHidden, "all", false, "Show hidden entries whose names start with a dot")
+	fs.BoolVar(&config.ShowHidden, "hidden", false, "Same as --all")
+	fs.BoolVar(&config.FollowSymlinks, "follow-symlinks", false, "Descend into symlinked directories, skipping links that loop back")
+	fs.BoolVar(&config.CollapseVCS, "collapse-vcs", false, "Show .git, .svn and .hg directories as single entries with their total size")
+	fs.Var(&f.include, "include", "Only show files matching these globs (comma-separated, repeatable)")
+	fs.Var(&f.exclude, "exclude", "Exclude files and folders matching these globs (comma-separated, repeatable)")
+	fs.Var(&f.includeRegex, "include-regex", "Only show files whose relative path matches this regex (repeatable)")
+	fs.Var(&f.excludeRegex, "exclude-regex", "Exclude files and folders whose relative path matches this regex (repeatable)")
+	fs.IntVar(&config.MaxDepth, "max-depth", -1, "Maximum depth to recurse (-1 for unlimited)")
+	fs.StringVar(&config.Sort, "sort", "name", "Sort entries by name, size, mtime, ext or natural")
+	fs.BoolVar(&config.Reverse, "reverse", false, "Reverse the sort order")
+	fs.BoolVar(&f.dirsFirst, "dirs-first", false, "List directories before files (default)")
+	fs.BoolVar(&f.dirsLast, "dirs-last", false, "List directories after files")
+	fs.BoolVar(&f.mixed, "mixed", false, "Sort directories and files together")
+	fs.StringVar(&f.charset, "charset", modeAuto, "Tree characters: auto, unicode or ascii (auto follows LC_ALL, LC_CTYPE and LANG)")
+	fs.BoolVar(&config.Unicode, "unicode", true, "Same as --charset=unicode, or --charset=ascii with --unicode=false")
+	f.color = modeAuto
+	fs.Var(colorFlag{&f.color}, "color", "Use colors: auto, always or never (auto honors NO_COLOR, CLICOLOR_FORCE and whether the output is a terminal)")
+	fs.BoolVar(&config.BgColor, "bg-color", false, "Use background color for items")
+	fs.StringVar(&f.theme, "theme", "", "Color theme: dark, light, ls or a theme file (default LS_COLORS if set, else dark)")
+	fs.BoolVar(&config.Compact, "compact", false, "Enable compact tree layout")
+	fs.BoolVar(&config.ShowSize, "show-size", false, "Show the size of each file")
+	fs.BoolVar(&config.ShowDirSize, "show-dir-size", false, "Show the cumulative size of each directory")
+	fs.BoolVar(&config.ShowPerms, "show-perms", false, "Show the permissions of each entry")
+	fs.BoolVar(&config.ShowOwner, "show-owner", false, "Show the owner and group of each entry")
+	fs.BoolVar(&config.ShowMtime, "show-mtime", false, "Show the modification time of each entry")
+	fs.BoolVar(&config.ShowInode, "show-inode", false, "Show the inode number of each entry")
+	fs.BoolVar(&config.GitAge, "git-age", false, "Show the last commit date, author and commit count of each entry, and the most changed and oldest paths")
+	fs.BoolVar(&config.Git, "git", false, "Mark entries with their git status: modified, staged, untracked, ignored or conflicted")
+	fs.BoolVar(&config.DiskUsage, "du", false, "Disk usage mode: show directory sizes with bars and percent of parent")
+	fs.BoolVar(&config.Interactive, "interactive", false, "Browse the tree in a full-screen terminal view")
+	fs.BoolVar(&config.Watch, "watch", false, "Re-render the tree when the directory changes (Linux only)")
+	fs.BoolVar(&config.WatchLog, "watch-log", false, "Print created, deleted, renamed and modified entries instead of re-rendering")
+	fs.BoolVar(&config.FindDuplicates, "find-duplicates", false, "Report groups of files with identical content instead of the tree")
+	fs.BoolVar(&config.ShowStats, "show-stats", false, "Show total files, dirs, size")
+	fs.BoolVar(&config.StatTable, "stat-table", false, "Show a table of largest files and types")
+	fs.StringVar(&config.GroupBy, "group-by", "ext", "Group the file type statistics by ext, language or category")
+	fs.IntVar(&config.StatsCount, "stats-count", 10, "Number of top files to show in stats table")
+	fs.BoolVar(&config.Chart, "chart", false, "Show a visual chart of file size distribution")
+	fs.StringVar(&config.Output, "output", "text", "Output format: text, json, html or markdown")
+	fs.BoolVar(&config.MarkdownList, "markdown-list", false, "With --output markdown, show the tree as a nested list with links")
+	fs.BoolVar(&config.Strict, "strict", false, "Exit with status 4 when any entry cannot be read")
+	fs.IntVar(&config.Jobs, "jobs", 1, "Number of directories to read in parallel (0 for one per CPU)")
+
+	fs.VisitAll(func(fl *flag.Flag) {
+		f.names = append(f.names, fl.Name)
+	})
+
+	// Registered last, as it selects the settings instead of being one
+	fs.StringVar(&f.profile, "profile", "", "Apply a named profile from the configuration file")
+	return f
+}
+
+// Check whether a flag was given on the command line
+func (f *configFlags) isSet(name string) bool {
+	set := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// Check that no arguments are left after the flags. As --color also works
+// alone, a mode given after a space, as in "--color never", is left over.
+func (f *configFlags) checkNoArgs() error {
+	if f.fs.NArg() == 0 {
+		return nil
+	}
+	arg := f.fs.Arg(0)
+	switch strings.ToLower(arg) {
+	case modeAuto, colorAlways, colorNever:
+		return fmt.Errorf("unexpected argument %q: give the mode with =, as in --color=%s", arg, arg)
+	}
+	return fmt.Errorf("unexpected argument %q", arg)
+}
+
+// Process the parsed flags into a validated Config
+func (f *configFlags) resolve() (Config, error) {
+	if err := f.applySettings(); err != nil {
+		return f.config, err
+	}
+	config := f.config
+
+	// Process comma-separated values into slices
+	config.ExcludeFolders = splitCommaString(f.excludeFolders)
+	config.ExcludeFiles = splitCommaString(f.excludeFiles)
+	config.ExcludeNames = splitCommaString(f.excludeNames)
+	config.Include = f.include
+	config.Exclude = f.exclude
+	config.IncludeRegex = f.includeRegex
+	config.ExcludeRegex = f.excludeRegex
+
+	// --unicode is kept for compatibility, --charset takes precedence
+	charset := f.charset
+	if !f.isSet("charset") && f.isSet("unicode") {
+		charset = charASCII
+		if config.Unicode {
+			charset = charUnicode
+		}
+	}
+	if !isValidCharset(charset) {
+		return config, fmt.Errorf("unknown charset %q (expected %s)", charset, strings.Join(charsetModes, ", "))
+	}
+	config.Unicode = useUnicode(charset)
+	config.Color = useColor(f.color, isStdoutTerminal())
+
+	theme, err := loadTheme(f.theme)
+	if err != nil {
+		return config, err
+	}
+	config.Theme = theme
+
+	// Disk usage mode lists the biggest entries first unless told otherwise
+	if config.DiskUsage && !f.isSet("sort") {
+		config.Sort = "size"
+	}
+
+	if !isValidGroupMode(config.GroupBy) {
+		return config, fmt.Errorf("unknown grouping %q (expected %s)", config.GroupBy, strings.Join(groupModes, ", "))
+	}
+
+	if !isValidSortMode(config.Sort) {
+		return config, fmt.Errorf("unknown sort mode %q (expected %s)", config.Sort, strings.Join(sortModes, ", "))
+	}
+
+	// Resolve the directory placement
+	config.DirOrder = dirsFirst
+	switch {
+	case f.dirsFirst && (f.dirsLast || f.mixed), f.dirsLast && f.mixed:
+		return config, fmt.Errorf("--dirs-first, --dirs-last and --mixed are mutually exclusive")
+	case f.dirsLast:
+		config.DirOrder = dirsLast
+	case f.mixed:
+		config.DirOrder = dirsMixed
+	}
+
+	// The event log reports files, shown or not
+	if config.WatchLog {
+		config.Watch = true
+		config.ShowFiles = true
+	}
+
+	// Duplicates are searched among all files, shown or not
+	if config.FindDuplicates {
+		config.ShowFiles = true
+	}
+
+	if config.Jobs == 0 {
+		config.Jobs = runtime.NumCPU()
+	}
+
+	if !isValidOutput(config.Output) {
+		return config, fmt.Errorf("unknown output format %q (expected %s)", config.Output, strings.Join(outputFormats, ", "))
+	}
+
+	if config.FindDuplicates && config.Output != "text" && config.Output != "json" {
+		return config, fmt.Errorf("--find-duplicates supports text and json output only")
+	}
+
+	if config.Interactive && config.Output != "text" {
+		return config, fmt.Errorf("--interactive cannot be combined with --output %s", config.Output)
+	}
+
+	if config.Interactive && config.Watch {
+		return config, fmt.Errorf("--interactive cannot be combined with --watch")
+	}
+
+	return config, nil
+}
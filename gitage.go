@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Format of the last commit dates
+const commitDateLayout = "2006-01-02"
+
+// GitHistory is the commit history of a file, or of everything below a
+// directory
+type GitHistory struct {
+	LastCommit time.Time
+	Author     string
+	Commits    int
+}
+
+// PathHistory is a row of the most changed and oldest path tables
+type PathHistory struct {
+	Path string
+	GitHistory
+}
+
+// Read the commit history of the scanned path, attach it to the nodes of
+// the tree and fill the most changed and oldest path tables
+func applyGitHistory(root *Node, config Config, stats *Stats) error {
+	dir, base, err := findGitBase(root, config.Path, "--git-age")
+	if err != nil {
+		return err
+	}
+
+	// Commits start with a \x01 header holding the date and author,
+	// followed by the changed paths
+	out, err := runGit(dir, "log", "--format=%x01%at%x09%an", "--name-only", "-z", "--no-renames", "--", ".")
+	if err != nil {
+		return fmt.Errorf("--git-age: %v", err)
+	}
+	histories := parseGitLog(out, base)
+
+	var walk func(node *Node, rel string)
+	walk = func(node *Node, rel string) {
+		node.History = histories[rel]
+		for _, child := range node.Children {
+			walk(child, pathJoin(rel, child.Name))
+		}
+	}
+	walk(root, "")
+
+	stats.MostChanged, stats.Oldest = historyTables(root)
+	return nil
+}
+
+// Parse the output of git log into the history of each path relative to
+// the scan root, "" being the root. A directory counts each commit that
+// changed anything below it once.
+func parseGitLog(out []byte, base string) map[string]*GitHistory {
+	histories := make(map[string]*GitHistory)
+
+	var date time.Time
+	var author string
+	var seen map[string]bool
+	for _, field := range strings.Split(string(out), "\x00") {
+		field = strings.TrimPrefix(field, "\n")
+		if field == "" {
+			continue
+		}
+
+		if field[0] == '\x01' {
+			header := strings.SplitN(field[1:], "\t", 2)
+			seconds, _ := strconv.ParseInt(header[0], 10, 64)
+			date = time.Unix(seconds, 0)
+			author = ""
+			if len(header) == 2 {
+				author = header[1]
+			}
+			seen = make(map[string]bool)
+			continue
+		}
+
+		rel, ok := relGitPath(field, base)
+		if !ok || seen == nil {
+			continue
+		}
+
+		// Count the commit for the path and each directory above it
+		for {
+			if seen[rel] {
+				break
+			}
+			seen[rel] = true
+
+			history := histories[rel]
+			if history == nil {
+				// The log starts with the newest commit
+				history = &GitHistory{LastCommit: date, Author: author}
+				histories[rel] = history
+			}
+			history.Commits++
+
+			if rel == "" {
+				break
+			}
+			rel = pathDir(rel)
+		}
+	}
+	return histories
+}
+
+// Get the parent of a slash-separated relative path, "" for the root
+func pathDir(rel string) string {
+	if i := strings.LastIndex(rel, "/"); i >= 0 {
+		return rel[:i]
+	}
+	return ""
+}
+
+// Get the files with the most commits and the paths whose last commit is
+// the oldest, below the root
+func historyTables(root *Node) ([]PathHistory, []PathHistory) {
+	var files, paths []PathHistory
+	var collect func(node *Node)
+	collect = func(node *Node) {
+		for _, child := range node.Children {
+			if child.History != nil {
+				row := PathHistory{Path: child.Path, GitHistory: *child.History}
+				paths = append(paths, row)
+				if !child.IsDir() {
+					files = append(files, row)
+				}
+			}
+			collect(child)
+		}
+	}
+	collect(root)
+
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Commits != files[j].Commits {
+			return files[i].Commits > files[j].Commits
+		}
+		return files[i].Path < files[j].Path
+	})
+	sort.SliceStable(paths, func(i, j int) bool {
+		if !paths[i].LastCommit.Equal(paths[j].LastCommit) {
+			return paths[i].LastCommit.Before(paths[j].LastCommit)
+		}
+		return paths[i].Path < paths[j].Path
+	})
+	return files, paths
+}
+
+// Get the git history columns of a node: last commit date, author and
+// number of commits
+func historyValues(node *Node) []string {
+	if node.History == nil {
+		return []string{"-", "-", ""}
+	}
+	return []string{
+		node.History.LastCommit.Format(commitDateLayout),
+		node.History.Author,
+		commitCount(node.History.Commits),
+	}
+}
+
+// Format a number of commits
+func commitCount(commits int) string {
+	if commits == 1 {
+		return "1 commit"
+	}
+	return fmt.Sprintf("%d commits", commits)
+}
+
+// Limit a table to the --stats-count first rows
+func limitHistory(rows []PathHistory, count int) []PathHistory {
+	if count >= 0 && len(rows) > count {
+		return rows[:count]
+	}
+	return rows
+}
+
+// Print the most changed files and the oldest paths
+func printHistory(w io.Writer, config Config, stats Stats) {
+	tables := []struct {
+		title string
+		rows  []PathHistory
+	}{
+		{"\n🔥 Most Changed Files:", stats.MostChanged},
+		{"\n🕰️ Oldest Untouched Paths:", stats.Oldest},
+	}
+
+	for _, table := range tables {
+		if len(table.rows) == 0 {
+			continue
+		}
+		fmt.Fprintln(w, table.title)
+
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, "  Commits\tLast Commit\tAuthor\tPath\t")
+		fmt.Fprintln(tw, "  -------\t-----------\t------\t----\t")
+		for _, row := range limitHistory(table.rows, config.StatsCount) {
+			relativePath, err := filepath.Rel(config.Path, row.Path)
+			if err != nil {
+				relativePath = row.Path
+			}
+			fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\t\n", row.Commits, row.LastCommit.Format(commitDateLayout), row.Author, relativePath)
+		}
+		tw.Flush()
+	}
+}
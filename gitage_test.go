@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGitLog(t *testing.T) {
+	// Newest commit first, as git log prints them
+	out := "\x01200\tBob\x00\nsrc/a.go\x00src/b.go\x00docs/guide.md\x00\x00" +
+		"\x01100\tAlice\x00\nsrc/a.go\x00other/x.go\x00"
+
+	tests := []struct {
+		base     string
+		path     string
+		commits  int
+		author   string
+		unixTime int64
+	}{
+		{".", "", 2, "Bob", 200},
+		{".", "src", 2, "Bob", 200},
+		{".", "src/a.go", 2, "Bob", 200},
+		{".", "src/b.go", 1, "Bob", 200},
+		{".", "other/x.go", 1, "Alice", 100},
+		{"src", "", 2, "Bob", 200},
+		{"src", "a.go", 2, "Bob", 200},
+		{"src", "b.go", 1, "Bob", 200},
+	}
+
+	for _, test := range tests {
+		history := parseGitLog([]byte(out), test.base)[test.path]
+		if history == nil {
+			t.Errorf("parseGitLog(%q): no history for %q", test.base, test.path)
+			continue
+		}
+		if history.Commits != test.commits || history.Author != test.author || history.LastCommit.Unix() != test.unixTime {
+			t.Errorf("parseGitLog(%q): expected %d commits by %s at %d for %q, got %+v",
+				test.base, test.commits, test.author, test.unixTime, test.path, *history)
+		}
+	}
+
+	if history := parseGitLog([]byte(out), "src")["../other/x.go"]; history != nil {
+		t.Errorf("parseGitLog(%q): expected paths outside the base to be skipped", "src")
+	}
+}
+
+func TestHistoryTables(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	root := &Node{Name: "root", Path: "root", Mode: fs.ModeDir, History: &GitHistory{LastCommit: day(5), Commits: 6}, Children: []*Node{
+		{Name: "src", Path: "root/src", Mode: fs.ModeDir, History: &GitHistory{LastCommit: day(5), Commits: 5}, Children: []*Node{
+			{Name: "a.go", Path: "root/src/a.go", History: &GitHistory{LastCommit: day(5), Commits: 2}},
+			{Name: "b.go", Path: "root/src/b.go", History: &GitHistory{LastCommit: day(3), Commits: 4}},
+			{Name: "new.go", Path: "root/src/new.go"},
+		}},
+		{Name: "old.txt", Path: "root/old.txt", History: &GitHistory{LastCommit: day(1), Commits: 2}},
+	}}
+
+	mostChanged, oldest := historyTables(root)
+
+	paths := func(rows []PathHistory) string {
+		var names []string
+		for _, row := range rows {
+			names = append(names, row.Path)
+		}
+		return strings.Join(names, ",")
+	}
+	if result := paths(mostChanged); result != "root/src/b.go,root/old.txt,root/src/a.go" {
+		t.Errorf("historyTables: unexpected most changed files %s", result)
+	}
+	if result := paths(oldest); result != "root/old.txt,root/src/b.go,root/src,root/src/a.go" {
+		t.Errorf("historyTables: unexpected oldest paths %s", result)
+	}
+	if result := paths(limitHistory(oldest, 2)); result != "root/old.txt,root/src/b.go" {
+		t.Errorf("limitHistory: unexpected rows %s", result)
+	}
+}
+
+func TestHistoryValues(t *testing.T) {
+	tests := []struct {
+		history  *GitHistory
+		expected string
+	}{
+		{nil, "-|-|"},
+		{&GitHistory{LastCommit: time.Date(2024, 3, 9, 12, 0, 0, 0, time.Local), Author: "Alice", Commits: 1}, "2024-03-09|Alice|1 commit"},
+		{&GitHistory{LastCommit: time.Date(2023, 12, 31, 12, 0, 0, 0, time.Local), Author: "Bob", Commits: 12}, "2023-12-31|Bob|12 commits"},
+	}
+
+	for _, test := range tests {
+		if result := strings.Join(historyValues(&Node{History: test.history}), "|"); result != test.expected {
+			t.Errorf("historyValues(%+v): expected %q, got %q", test.history, test.expected, result)
+		}
+	}
+}
+
+func TestScanTreeGitAge(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tempDir := createTestTree(t)
+	git := func(date string, args ...string) {
+		t.Helper()
+		args = append([]string{"-C", tempDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("2024-01-01T12:00:00", "init", "-q")
+	git("2024-01-01T12:00:00", "add", ".")
+	git("2024-01-01T12:00:00", "commit", "-q", "-m", "initial")
+	git("2024-02-01T12:00:00", "commit", "-q", "--allow-empty", "-m", "empty")
+	if err := os.WriteFile(filepath.Join(tempDir, "dir1", "file3.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("2024-03-01T12:00:00", "commit", "-q", "-a", "-m", "change")
+
+	config := testConfig(tempDir)
+	config.GitAge = true
+	stats := newTestStats()
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	dir1 := findChild(root, "dir1")
+	if dir1 == nil || dir1.History == nil || dir1.History.Commits != 2 || dir1.History.LastCommit.Format(commitDateLayout) != "2024-03-01" {
+		t.Fatalf("Expected dir1 to have 2 commits, last on 2024-03-01, got %+v", dir1)
+	}
+	if len(stats.MostChanged) == 0 || !strings.HasSuffix(stats.MostChanged[0].Path, "file3.txt") {
+		t.Errorf("Expected file3.txt to be the most changed file, got %+v", stats.MostChanged)
+	}
+	if len(stats.Oldest) == 0 || stats.Oldest[0].LastCommit.Format(commitDateLayout) != "2024-01-01" {
+		t.Errorf("Expected the oldest path to date from 2024-01-01, got %+v", stats.Oldest)
+	}
+
+	var buf bytes.Buffer
+	printHistory(&buf, config, stats)
+	for _, title := range []string{"Most Changed Files:", "Oldest Untouched Paths:"} {
+		if !strings.Contains(buf.String(), title) {
+			t.Errorf("Expected %q in the history tables:\n%s", title, buf.String())
+		}
+	}
+
+	// Scanning outside a repository fails on the git data, not the path
+	config.Path = t.TempDir()
+	if _, err := scanTree(config, &stats); !isGitError(err) {
+		t.Errorf("Expected a git error outside a git repository, got %v", err)
+	}
+}
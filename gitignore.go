@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a single pattern from a gitignore file
+type ignoreRule struct {
+	pattern string // glob relative to the ignore root
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher holds the gitignore rules in effect for a directory.
+// Matchers are never modified once built, so scan workers can share them.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// Find the directory that gitignore paths are relative to: the enclosing
+// repository root, or the path itself when it is not inside a repository
+func findIgnoreRoot(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		if filepath.Dir(dir) == dir {
+			return abs, nil
+		}
+	}
+}
+
+// Build the matcher for the scan root: .git/info/exclude plus every
+// .gitignore between the ignore root and the scan root's parent
+func newIgnoreMatcher(root string, scanPath string) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{}
+	m = m.withFile(filepath.Join(root, ".git", "info", "exclude"), "")
+
+	abs, err := filepath.Abs(scanPath)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." {
+		return m, err
+	}
+
+	m = m.withFile(filepath.Join(root, ".gitignore"), "")
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		m = m.withFile(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"), dir)
+	}
+	return m, nil
+}
+
+// Return a matcher extended with the rules of a gitignore file located in
+// dir (relative to the ignore root). Missing files add no rules.
+func (m *ignoreMatcher) withFile(file string, dir string) *ignoreMatcher {
+	f, err := os.Open(file)
+	if err != nil {
+		return m
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text(), dir); ok {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return m
+	}
+
+	combined := make([]ignoreRule, 0, len(m.rules)+len(rules))
+	combined = append(combined, m.rules...)
+	combined = append(combined, rules...)
+	return &ignoreMatcher{rules: combined}
+}
+
+// Parse one line of a gitignore file
+func parseIgnoreLine(line string, dir string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// Trailing spaces are ignored unless escaped with a backslash
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// A pattern without a slash matches at any depth below its file,
+	// otherwise it is anchored to the directory of the gitignore file
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+	if dir != "" {
+		line = path.Join(dir, line)
+	}
+
+	rule.pattern = line
+	return rule, true
+}
+
+// Check whether a path (slash-separated, relative to the ignore root) is
+// ignored. The last matching rule wins.
+func (m *ignoreMatcher) isIgnored(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		rule := m.rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchGlob(rule.pattern, relPath) {
+			return !rule.negate
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestParseIgnoreLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		dir     string
+		ok      bool
+		pattern string
+		negate  bool
+		dirOnly bool
+	}{
+		{"", "", false, "", false, false},
+		{"# comment", "", false, "", false, false},
+		{"*.log", "", true, "**/*.log", false, false},
+		{"*.log", "sub", true, "sub/**/*.log", false, false},
+		{"/build", "", true, "build", false, false},
+		{"build/", "", true, "**/build", false, true},
+		{"!keep.log", "", true, "**/keep.log", true, false},
+		{"\\#file", "", true, "**/#file", false, false},
+		{"docs/*.md   ", "sub", true, "sub/docs/*.md", false, false},
+		{"a/**/b", "", true, "a/**/b", false, false},
+	}
+
+	for _, test := range tests {
+		rule, ok := parseIgnoreLine(test.line, test.dir)
+		if ok != test.ok {
+			t.Errorf("parseIgnoreLine(%q, %q): expected ok=%v, got %v", test.line, test.dir, test.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if rule.pattern != test.pattern || rule.negate != test.negate || rule.dirOnly != test.dirOnly {
+			t.Errorf("parseIgnoreLine(%q, %q): expected {%q %v %v}, got {%q %v %v}",
+				test.line, test.dir, test.pattern, test.negate, test.dirOnly,
+				rule.pattern, rule.negate, rule.dirOnly)
+		}
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	var m ignoreMatcher
+	for _, line := range []string{"*.log", "!important.log", "/out", "tmp/", "docs/**/draft.md", "foo/**", "!foo/keep.txt"} {
+		rule, _ := parseIgnoreLine(line, "")
+		m.rules = append(m.rules, rule)
+	}
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"debug.log", false, true},
+		{"src/debug.log", false, true},
+		{"important.log", false, false},
+		{"out", true, true},
+		{"src/out", true, false},
+		{"tmp", true, true},
+		{"tmp", false, false},
+		{"docs/a/b/draft.md", false, true},
+		{"main.go", false, false},
+		{"foo", true, false},
+		{"foo/drop.txt", false, true},
+		{"foo/keep.txt", false, false},
+	}
+
+	for _, test := range tests {
+		result := m.isIgnored(test.path, test.isDir)
+		if result != test.expected {
+			t.Errorf("isIgnored(%q, %v): expected %v, got %v", test.path, test.isDir, test.expected, result)
+		}
+	}
+}
+
+func TestScanTreeGitignore(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "hyperion-gitignore")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		".git/info/exclude": "secret.txt\n",
+		".git/HEAD":         "ref: refs/heads/main\n",
+		".gitignore":        "*.log\n/build/\n",
+		"app.log":           "",
+		"main.go":           "",
+		"secret.txt":        "",
+		"build/out.bin":     "",
+		"src/.gitignore":    "!keep.log\ngen/\n",
+		"src/keep.log":      "",
+		"src/drop.log.txt":  "",
+		"src/gen/code.go":   "",
+		"src/build/x.go":    "",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+
+	config := Config{Path: tempDir, ShowFiles: true, ShowHidden: true, MaxDepth: -1, Gitignore: true}
+	stats := newTestStats()
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	var paths []string
+	var collect func(node *Node, prefix string)
+	collect = func(node *Node, prefix string) {
+		for _, child := range node.Children {
+			paths = append(paths, prefix+child.Name)
+			collect(child, prefix+child.Name+"/")
+		}
+	}
+	collect(root, "")
+	sort.Strings(paths)
+
+	expected := []string{
+		".gitignore",
+		"main.go",
+		"src",
+		"src/.gitignore",
+		"src/build",
+		"src/build/x.go",
+		"src/drop.log.txt",
+		"src/keep.log",
+	}
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected paths %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("Path %d: expected %q, got %q", i, expected[i], paths[i])
+		}
+	}
+
+	// Scanning a subdirectory still applies the parent rules
+	config.Path = filepath.Join(tempDir, "src")
+	stats = newTestStats()
+	root, err = scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree of subdirectory failed: %v", err)
+	}
+	for _, child := range root.Children {
+		if child.Name == "gen" {
+			t.Errorf("Expected src/gen to be ignored when scanning the subdirectory")
+		}
+	}
+	if stats.TotalFiles != 4 {
+		t.Errorf("Expected 4 files in src, got %d", stats.TotalFiles)
+	}
+
+	// With --collapse-vcs, .git is listed as a single entry
+	config.Path = tempDir
+	config.CollapseVCS = true
+	stats = newTestStats()
+	root, err = scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree with collapse-vcs failed: %v", err)
+	}
+	if git := root.Children[0]; git.Name != ".git" || !git.Collapsed {
+		t.Errorf("Expected a collapsed .git directory with gitignore, got %q (collapsed %v)", git.Name, git.Collapsed)
+	}
+}
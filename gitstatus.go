@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// gitStatus is a set of git states of an entry. Directories hold the
+// states of the entries below them, except ignored.
+type gitStatus uint8
+
+const (
+	gitModified gitStatus = 1 << iota
+	gitStaged
+	gitUntracked
+	gitIgnored
+	gitConflicted
+)
+
+// Marker letter, name and color of each state, in display order
+var gitStates = []struct {
+	status gitStatus
+	code   string
+	name   string
+	color  color.Attribute
+	bg     color.Attribute
+}{
+	{gitConflicted, "U", "conflicted", color.FgRed, color.BgRed},
+	{gitStaged, "S", "staged", color.FgGreen, color.BgGreen},
+	{gitModified, "M", "modified", color.FgYellow, color.BgYellow},
+	{gitUntracked, "?", "untracked", color.FgCyan, color.BgCyan},
+	{gitIgnored, "!", "ignored", color.FgHiBlack, color.BgHiBlack},
+}
+
+// Get the marker letters of a status, such as "SM" for a file with staged
+// and unstaged changes
+func (s gitStatus) code() string {
+	var code strings.Builder
+	for _, state := range gitStates {
+		if s&state.status != 0 {
+			code.WriteString(state.code)
+		}
+	}
+	return code.String()
+}
+
+// Get the names of the states in a status
+func (s gitStatus) names() []string {
+	var names []string
+	for _, state := range gitStates {
+		if s&state.status != 0 {
+			names = append(names, state.name)
+		}
+	}
+	return names
+}
+
+// Read the git status of the repository containing the scanned path and
+// mark the nodes of the tree with it
+func applyGitStatus(root *Node, rootPath string) error {
+	dir, base, err := findGitBase(root, rootPath, "--git")
+	if err != nil {
+		return err
+	}
+
+	out, err := runGit(dir, "status", "--porcelain=v1", "-z", "--untracked-files=all", "--ignored=matching", "--", ".")
+	if err != nil {
+		return fmt.Errorf("--git: %v", err)
+	}
+
+	for path, status := range parseGitStatus(out) {
+		if rel, ok := relGitPath(strings.TrimSuffix(path, "/"), base); ok {
+			markGitPath(root, rel, status)
+		}
+	}
+	return nil
+}
+
+// Find the directory to run git in for a scanned path, and the path of the
+// scan root relative to the top of the repository, slash-separated. flag
+// names the option in error messages.
+func findGitBase(root *Node, rootPath string, flag string) (string, string, error) {
+	dir, err := filepath.Abs(rootPath)
+	if err != nil {
+		return "", "", err
+	}
+	if !root.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	top, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", "", fmt.Errorf("%s needs the git command: %v", flag, err)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %s is not in a git repository", flag, rootPath)
+	}
+
+	// git reports paths from the top of the repository, with symlinks
+	// in the scanned path resolved
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", "", err
+	}
+	base, err := filepath.Rel(strings.TrimSpace(string(top)), real)
+	if err != nil {
+		return "", "", err
+	}
+	base = filepath.ToSlash(base)
+	if !root.IsDir() {
+		base = pathJoin(base, root.Name)
+	}
+	return dir, base, nil
+}
+
+// Get a path reported by git relative to the scan root, if it is below it
+func relGitPath(path, base string) (string, bool) {
+	if base == "." {
+		return path, true
+	}
+	if path == base {
+		return "", true
+	}
+	if !strings.HasPrefix(path, base+"/") {
+		return "", false
+	}
+	return path[len(base)+1:], true
+}
+
+// Run a git command in a directory and return its output
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// gitError is a failure to read the git data of --git or --git-age. The
+// scanned path itself could be read, so it is not reported as a path error.
+type gitError struct {
+	err error
+}
+
+func (e *gitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *gitError) Unwrap() error {
+	return e.err
+}
+
+// Check whether a scan failed on its git data rather than on the path
+func isGitError(err error) bool {
+	var gitErr *gitError
+	return errors.As(err, &gitErr)
+}
+
+// Join two slash-separated paths, where "." is the empty path
+func pathJoin(dir, name string) string {
+	if dir == "." || dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// Parse the output of git status --porcelain=v1 -z into the status of
+// each path. Ignored directories keep their trailing slash.
+func parseGitStatus(out []byte) map[string]gitStatus {
+	statuses := make(map[string]gitStatus)
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if len(field) < 4 {
+			continue
+		}
+		x, y, path := field[0], field[1], field[3:]
+		statuses[path] |= statusFromXY(x, y)
+
+		// Renames and copies are followed by their original path
+		if x == 'R' || x == 'C' {
+			i++
+		}
+	}
+	return statuses
+}
+
+// Convert the two status letters of git status --porcelain to a status
+func statusFromXY(x, y byte) gitStatus {
+	switch {
+	case x == '?' && y == '?':
+		return gitUntracked
+	case x == '!' && y == '!':
+		return gitIgnored
+	case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+		return gitConflicted
+	}
+
+	var status gitStatus
+	if x != ' ' {
+		status |= gitStaged
+	}
+	if y != ' ' {
+		status |= gitModified
+	}
+	return status
+}
+
+// Mark the node at a slash-separated path relative to the root, and roll
+// the status up to the directories above it. Paths missing from the tree,
+// such as deleted or filtered files, still mark their parents.
+func markGitPath(root *Node, path string, status gitStatus) {
+	node := root
+	if path != "" {
+		for _, name := range strings.Split(path, "/") {
+			if status != gitIgnored {
+				node.Git |= status
+			}
+			node = findChild(node, name)
+			if node == nil {
+				return
+			}
+		}
+	}
+
+	if status == gitIgnored {
+		markIgnored(node)
+		return
+	}
+	node.Git |= status
+}
+
+// Get the child of a directory with a name
+func findChild(node *Node, name string) *Node {
+	for _, child := range node.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Mark a node and everything below it as ignored
+func markIgnored(node *Node) {
+	node.Git |= gitIgnored
+	for _, child := range node.Children {
+		markIgnored(child)
+	}
+}
+
+// Get the width of the status markers of the entries below the root
+func gitMarkWidth(root *Node) int {
+	width := 0
+	var measure func(node *Node)
+	measure = func(node *Node) {
+		for _, child := range node.Children {
+			if n := len(child.Git.code()); n > width {
+				width = n
+			}
+			measure(child)
+		}
+	}
+	measure(root)
+	return width
+}
+
+// Write the status markers of an entry padded to width, each letter in
+// its color when colored is set
+func writeGitMark(w io.Writer, status gitStatus, width int, colored, bgColor bool) {
+	if width == 0 {
+		return
+	}
+	code := status.code()
+	if colored {
+		for _, state := range gitStates {
+			if status&state.status == 0 {
+				continue
+			}
+			if bgColor {
+				newColor(color.FgHiWhite, state.bg).Fprint(w, state.code)
+			} else {
+				newColor(state.color).Fprint(w, state.code)
+			}
+		}
+	} else {
+		fmt.Fprint(w, code)
+	}
+	fmt.Fprint(w, strings.Repeat(" ", width-len(code)+1))
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatusFromXY(t *testing.T) {
+	tests := []struct {
+		xy       string
+		expected gitStatus
+	}{
+		{"??", gitUntracked},
+		{"!!", gitIgnored},
+		{" M", gitModified},
+		{"M ", gitStaged},
+		{"MM", gitStaged | gitModified},
+		{"A ", gitStaged},
+		{" D", gitModified},
+		{"R ", gitStaged},
+		{"UU", gitConflicted},
+		{"AA", gitConflicted},
+		{"DU", gitConflicted},
+	}
+
+	for _, test := range tests {
+		if result := statusFromXY(test.xy[0], test.xy[1]); result != test.expected {
+			t.Errorf("statusFromXY(%q): expected %q, got %q", test.xy, test.expected.code(), result.code())
+		}
+	}
+}
+
+func TestParseGitStatus(t *testing.T) {
+	out := " M src/a.go\x00R  new.go\x00old.go\x00?? notes.txt\x00!! build/\x00"
+	statuses := parseGitStatus([]byte(out))
+
+	expected := map[string]gitStatus{
+		"src/a.go":  gitModified,
+		"new.go":    gitStaged,
+		"notes.txt": gitUntracked,
+		"build/":    gitIgnored,
+	}
+	if len(statuses) != len(expected) {
+		t.Fatalf("Expected %d paths, got %v", len(expected), statuses)
+	}
+	for path, status := range expected {
+		if statuses[path] != status {
+			t.Errorf("parseGitStatus: expected %q for %s, got %q", status.code(), path, statuses[path].code())
+		}
+	}
+}
+
+func TestGitStatusCode(t *testing.T) {
+	tests := []struct {
+		status gitStatus
+		code   string
+	}{
+		{0, ""},
+		{gitModified, "M"},
+		{gitModified | gitStaged, "SM"},
+		{gitUntracked | gitConflicted | gitModified, "UM?"},
+	}
+
+	for _, test := range tests {
+		if result := test.status.code(); result != test.code {
+			t.Errorf("code(%d): expected %q, got %q", test.status, test.code, result)
+		}
+	}
+}
+
+func TestMarkGitPath(t *testing.T) {
+	root := &Node{Name: "root", Mode: fs.ModeDir, Children: []*Node{
+		{Name: "src", Mode: fs.ModeDir, Children: []*Node{
+			{Name: "a.go"},
+			{Name: "b.go"},
+		}},
+		{Name: "build", Mode: fs.ModeDir, Children: []*Node{
+			{Name: "out.bin"},
+		}},
+	}}
+	src, build := root.Children[0], root.Children[1]
+
+	markGitPath(root, "src/a.go", gitModified)
+	markGitPath(root, "src/gone.go", gitStaged)
+	markGitPath(root, "build", gitIgnored)
+
+	tests := []struct {
+		name     string
+		node     *Node
+		expected gitStatus
+	}{
+		{"root", root, gitModified | gitStaged},
+		{"src", src, gitModified | gitStaged},
+		{"src/a.go", src.Children[0], gitModified},
+		{"src/b.go", src.Children[1], 0},
+		{"build", build, gitIgnored},
+		{"build/out.bin", build.Children[0], gitIgnored},
+	}
+	for _, test := range tests {
+		if test.node.Git != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected.code(), test.node.Git.code())
+		}
+	}
+}
+
+func TestScanTreeGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tempDir := createTestTree(t)
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-C", tempDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	if err := os.WriteFile(filepath.Join(tempDir, "dir1", "file3.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "file5.txt"), []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := testConfig(tempDir)
+	config.Git = true
+	stats := newTestStats()
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	statuses := make(map[string]gitStatus)
+	for _, child := range root.Children {
+		statuses[child.Name] = child.Git
+	}
+	expected := map[string]gitStatus{
+		"dir1":      gitModified,
+		"dir2":      0,
+		"file1.txt": 0,
+		"file5.txt": gitUntracked,
+	}
+	for name, status := range expected {
+		if statuses[name] != status {
+			t.Errorf("%s: expected %q, got %q", name, status.code(), statuses[name].code())
+		}
+	}
+
+	// Scanning outside a repository fails on the git data, not the path
+	config.Path = t.TempDir()
+	if _, err := scanTree(config, &stats); !isGitError(err) {
+		t.Errorf("Expected a git error outside a git repository, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// Match a slash-separated path against a glob pattern. Besides the
+// path.Match syntax, a "**" segment matches zero or more whole path
+// segments, one or more at the end of the pattern, and "[!...]" negates a
+// character class.
+func matchGlob(pattern string, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// Match path segments against pattern segments
+func matchSegments(pattern []string, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Skip repeated "**" segments
+			rest := pattern[1:]
+			for len(rest) > 0 && rest[0] == "**" {
+				rest = rest[1:]
+			}
+			// A trailing "**" matches everything inside, but not the
+			// directory itself
+			if len(rest) == 0 {
+				return len(parts) > 0
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 || !matchSegment(pattern[0], parts[0]) {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// Match a single path segment, treating malformed patterns as no match
+func matchSegment(pattern string, name string) bool {
+	pattern = strings.ReplaceAll(pattern, "[!", "[^")
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
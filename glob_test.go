@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/tool/main.go", true},
+		{"build-*", "build-linux", true},
+		{"build-*", "builds", false},
+		{"vendor/**/testdata", "vendor/testdata", true},
+		{"vendor/**/testdata", "vendor/a/b/testdata", true},
+		{"vendor/**/testdata", "vendor/a/b/testdata/x", false},
+		{"docs/**", "docs/guide/index.md", true},
+		{"docs/**", "docs", false},
+		{"file?.txt", "file1.txt", true},
+		{"file[!0-9].txt", "file1.txt", false},
+		{"file[!0-9].txt", "fileA.txt", true},
+		{"[", "[", false},
+	}
+
+	for _, test := range tests {
+		result := matchGlob(test.pattern, test.name)
+		if result != test.expected {
+			t.Errorf("matchGlob(%q, %q): expected %v, got %v", test.pattern, test.name, test.expected, result)
+		}
+	}
+}
@@ -2,10 +2,12 @@ module github.com/Anouar-A-Alaoui/hyperion
 
 go 1.18
 
-require github.com/fatih/color v1.15.0
-
 require (
-	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.17 // indirect
-	golang.org/x/sys v0.6.0 // indirect
+	github.com/BurntSushi/toml v1.3.2
+	github.com/fatih/color v1.15.0
+	github.com/mattn/go-isatty v0.0.17
+	golang.org/x/sys v0.6.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/mattn/go-colorable v0.1.13 // indirect
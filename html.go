@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Colors of the chart bars and pie slices
+var htmlChartColors = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+// Color of the pie slice for the types left out of the chart
+const htmlOtherColor = "#d3d3d3"
+
+// Width in pixels of the longest bar of the file type chart
+const htmlBarWidth = 300
+
+// htmlReport is the data of the HTML report template
+type htmlReport struct {
+	Title      string
+	Generated  string
+	Root       *htmlNode
+	Stats      Stats
+	TotalSize  string
+	LargeFiles []htmlFile
+	Types      []htmlType
+	BarHeight  int
+	Slices     []htmlSlice
+}
+
+// htmlNode is a tree entry in the HTML report
+type htmlNode struct {
+	Name     string
+	Class    string
+	Size     string
+	Target   string
+	Git      string
+	Error    string
+	Open     bool
+	Children []*htmlNode
+}
+
+// htmlFile is a row of the largest files table
+type htmlFile struct {
+	Path  string
+	Type  string
+	Size  string
+	Bytes int64
+}
+
+// htmlType is a bar of the file type chart
+type htmlType struct {
+	Ext     string
+	Size    string
+	Percent string
+	Color   string
+	Y       int
+	Width   float64
+}
+
+// htmlSlice is a slice of the file type pie chart
+type htmlSlice struct {
+	Label string
+	Color string
+	Path  string
+}
+
+// Write a self-contained HTML report with the tree, the largest files and
+// the file type distribution
+func writeHTML(w io.Writer, root *Node, stats Stats, config Config) error {
+	report := htmlReport{
+		Title:     root.Name,
+		Generated: time.Now().Format("2006-01-02 15:04:05"),
+		Root:      toHTMLNode(root, 0),
+		Stats:     stats,
+		TotalSize: formatSize(stats.TotalSize),
+	}
+
+	// Largest files, as in the stat table
+	largeFiles := append([]FileInfo(nil), stats.LargeFiles...)
+	sort.SliceStable(largeFiles, func(i, j int) bool {
+		return largeFiles[i].Size > largeFiles[j].Size
+	})
+	if config.StatsCount >= 0 && len(largeFiles) > config.StatsCount {
+		largeFiles = largeFiles[:config.StatsCount]
+	}
+	for _, file := range largeFiles {
+		relativePath, err := filepath.Rel(config.Path, file.Path)
+		if err != nil {
+			relativePath = file.Path
+		}
+		report.LargeFiles = append(report.LargeFiles, htmlFile{
+			Path:  relativePath,
+			Type:  file.Type,
+			Size:  formatSize(file.Size),
+			Bytes: file.Size,
+		})
+	}
+
+	// File type distribution, as in the charts
+	types := fileTypeDistribution(stats, config.StatsCount)
+	var maxSize, shown int64
+	for _, info := range types {
+		if info.Size > maxSize {
+			maxSize = info.Size
+		}
+		shown += info.Size
+	}
+	for i, info := range types {
+		width := 0.0
+		if maxSize > 0 {
+			width = float64(info.Size) / float64(maxSize) * htmlBarWidth
+		}
+		report.Types = append(report.Types, htmlType{
+			Ext:     info.Ext,
+			Size:    formatSize(info.Size),
+			Percent: fmt.Sprintf("%.1f%%", percentOf(info.Size, stats.TotalSize)),
+			Color:   htmlChartColors[i%len(htmlChartColors)],
+			Y:       i * 24,
+			Width:   width,
+		})
+	}
+	report.BarHeight = len(types) * 24
+
+	// The pie covers all files, grouping the types left out of the chart
+	var start float64
+	for i, info := range types {
+		end := start + percentOf(info.Size, stats.TotalSize)
+		report.Slices = append(report.Slices, htmlSlice{
+			Label: info.Ext,
+			Color: htmlChartColors[i%len(htmlChartColors)],
+			Path:  pieSlicePath(start, end),
+		})
+		start = end
+	}
+	if other := stats.TotalSize - shown; other > 0 {
+		report.Slices = append(report.Slices, htmlSlice{
+			Label: "other",
+			Color: htmlOtherColor,
+			Path:  pieSlicePath(start, 100),
+		})
+	}
+
+	return htmlTemplate.Execute(w, report)
+}
+
+// Convert a tree node to the HTML report representation. The first two
+// levels start expanded.
+func toHTMLNode(node *Node, depth int) *htmlNode {
+	hn := &htmlNode{
+		Name:   node.Name,
+		Class:  nodeType(node),
+		Size:   formatSize(node.Size),
+		Target: node.LinkTarget,
+		Git:    node.Git.code(),
+		Open:   depth < 2,
+	}
+	if node.LinkBroken {
+		hn.Class = "broken"
+	} else if node.FileType.Category != "" {
+		hn.Class += " " + node.FileType.Category
+	}
+	if node.Err != nil {
+		hn.Error = node.Err.Error()
+	}
+	for _, child := range node.Children {
+		hn.Children = append(hn.Children, toHTMLNode(child, depth+1))
+	}
+	return hn
+}
+
+// Get a size as a percentage of a total
+func percentOf(size, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(size) / float64(total) * 100
+}
+
+// Get the SVG path of a pie slice between two percentages, on a circle of
+// radius 100 centered at (100, 100)
+func pieSlicePath(start, end float64) string {
+	if end-start >= 99.999 {
+		return "M 100 0 A 100 100 0 1 1 99.99 0 Z"
+	}
+
+	point := func(percent float64) (float64, float64) {
+		angle := percent/100*2*math.Pi - math.Pi/2
+		return 100 + 100*math.Cos(angle), 100 + 100*math.Sin(angle)
+	}
+	x0, y0 := point(start)
+	x1, y1 := point(end)
+	largeArc := 0
+	if end-start > 50 {
+		largeArc = 1
+	}
+	return fmt.Sprintf("M 100 100 L %.2f %.2f A 100 100 0 %d 1 %.2f %.2f Z", x0, y0, largeArc, x1, y1)
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>hyperion - {{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ddd; padding-bottom: .3em; }
+.summary span { margin-right: 2em; }
+.tree, .tree ul { list-style: none; padding-left: 1.2em; font-family: Menlo, Consolas, monospace; font-size: .9em; }
+.tree { padding-left: 0; }
+.tree summary { cursor: pointer; }
+.tree li > span, .tree summary { line-height: 1.5em; }
+.directory { color: #1f5fbf; font-weight: bold; }
+.file { color: #2e7d32; }
+.symlink { color: #8e24aa; }
+.broken { color: #c62828; text-decoration: line-through; }
+.other { color: #666; }
+.file.document { color: #444; }
+.file.image { color: #ad1457; }
+.file.media { color: #00838f; }
+.file.archive { color: #d84315; }
+.file.binary { color: #558b2f; font-weight: bold; }
+.file.data { color: #9e7700; }
+.error { color: #c62828; }
+.git { color: #b26a00; font-weight: bold; margin-right: .4em; }
+.size { color: #888; font-weight: normal; margin-left: .5em; }
+table { border-collapse: collapse; font-size: .9em; }
+th, td { text-align: left; padding: .3em 1em; border-bottom: 1px solid #eee; }
+th { cursor: pointer; user-select: none; background: #f6f6f6; }
+th.sorted-asc::after { content: " \25B2"; }
+th.sorted-desc::after { content: " \25BC"; }
+td.number { text-align: right; }
+.charts { display: flex; flex-wrap: wrap; gap: 3em; align-items: flex-start; }
+.legend { list-style: none; padding: 0; font-size: .9em; }
+.legend li { margin: .2em 0; }
+.swatch { display: inline-block; width: .9em; height: .9em; margin-right: .4em; vertical-align: middle; }
+button { margin-right: .5em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="summary">
+<span>Directories: <b>{{.Stats.TotalDirs}}</b></span>
+<span>Files: <b>{{.Stats.TotalFiles}}</b></span>
+<span>Total size: <b>{{.TotalSize}}</b></span>
+<span>Generated: {{.Generated}}</span>
+</p>
+
+<h2>Tree</h2>
+<p><button onclick="setOpen(true)">Expand all</button><button onclick="setOpen(false)">Collapse all</button></p>
+<ul class="tree">{{template "node" .Root}}</ul>
+{{if .LargeFiles}}
+<h2>Largest Files</h2>
+<table id="largest">
+<thead><tr><th data-type="number">Size</th><th>Path</th><th>Type</th></tr></thead>
+<tbody>
+{{- range .LargeFiles}}
+<tr><td class="number" data-value="{{.Bytes}}">{{.Size}}</td><td>{{.Path}}</td><td>{{.Type}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{end}}
+{{- if .Types}}
+<h2>File Type Distribution</h2>
+<div class="charts">
+<svg width="520" height="{{.BarHeight}}" viewBox="0 0 520 {{.BarHeight}}" role="img" aria-label="File size by type">
+{{- range .Types}}
+<g transform="translate(0 {{.Y}})"><text x="110" y="15" text-anchor="end" font-size="12">{{.Ext}}</text><rect x="120" y="3" width="{{.Width}}" height="16" fill="{{.Color}}"></rect><text x="430" y="15" font-size="12">{{.Percent}} ({{.Size}})</text></g>
+{{- end}}
+</svg>
+<div>
+<svg width="200" height="200" viewBox="0 0 200 200" role="img" aria-label="Share of total size by type">
+{{- range .Slices}}
+<path d="{{.Path}}" fill="{{.Color}}" stroke="#fff" stroke-width="1"><title>{{.Label}}</title></path>
+{{- end}}
+</svg>
+<ul class="legend">
+{{- range .Slices}}
+<li><span class="swatch" style="background: {{.Color}}"></span>{{.Label}}</li>
+{{- end}}
+</ul>
+</div>
+</div>
+{{end}}
+<script>
+function setOpen(open) {
+  document.querySelectorAll(".tree details").forEach(function (d) { d.open = open; });
+}
+document.querySelectorAll("table th").forEach(function (th, column) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table");
+    var body = table.tBodies[0];
+    var asc = !th.classList.contains("sorted-asc");
+    var numeric = th.dataset.type === "number";
+    table.querySelectorAll("th").forEach(function (h) { h.classList.remove("sorted-asc", "sorted-desc"); });
+    th.classList.add(asc ? "sorted-asc" : "sorted-desc");
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[column], y = b.cells[column];
+      var cmp = numeric ? x.dataset.value - y.dataset.value : x.textContent.localeCompare(y.textContent);
+      return asc ? cmp : -cmp;
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+{{define "node"}}<li>
+{{- if .Children}}<details{{if .Open}} open{{end}}><summary>{{if .Git}}<span class="git">{{.Git}}</span>{{end}}<span class="{{.Class}}">{{.Name}}</span>{{if .Target}} -&gt; {{.Target}}{{end}}<span class="size">{{.Size}}</span></summary><ul>
+{{- range .Children}}{{template "node" .}}{{end}}</ul></details>
+{{- else}}{{if .Git}}<span class="git">{{.Git}}</span>{{end}}<span class="{{.Class}}">{{.Name}}</span>{{if .Target}} -&gt; {{.Target}}{{end}}<span class="size">{{.Size}}</span>
+{{- end}}
+{{- if .Error}} <span class="error">{{.Error}}</span>{{end}}</li>
+{{end}}`))
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPieSlicePath(t *testing.T) {
+	tests := []struct {
+		start, end float64
+		expected   string
+	}{
+		{0, 25, "M 100 100 L 100.00 0.00 A 100 100 0 0 1 200.00 100.00 Z"},
+		{25, 100, "M 100 100 L 200.00 100.00 A 100 100 0 1 1 100.00 0.00 Z"},
+		{0, 100, "M 100 0 A 100 100 0 1 1 99.99 0 Z"},
+	}
+
+	for _, test := range tests {
+		result := pieSlicePath(test.start, test.end)
+		if result != test.expected {
+			t.Errorf("pieSlicePath(%v, %v): expected %q, got %q", test.start, test.end, test.expected, result)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	tempDir := createTestTree(t)
+	for _, name := range []string{"<b>.txt", "notes.log"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", name, err)
+		}
+	}
+
+	config := testConfig(tempDir)
+	config.Output = "html"
+	config.StatsCount = 1
+	stats := newTestStats()
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeHTML(&buf, root, stats, config); err != nil {
+		t.Fatalf("writeHTML failed: %v", err)
+	}
+	report := buf.String()
+
+	expected := []string{
+		"<!DOCTYPE html>",
+		`<span class="directory">subdir1</span>`,
+		`<span class="file document">file4.txt</span>`,
+		"&lt;b&gt;.txt",
+		`<table id="largest">`,
+		"<h2>File Type Distribution</h2>",
+		"<title>other</title>",
+	}
+	for _, text := range expected {
+		if !strings.Contains(report, text) {
+			t.Errorf("Expected the report to contain %q", text)
+		}
+	}
+
+	if strings.Contains(report, "<b>.txt") {
+		t.Errorf("Expected file names to be escaped")
+	}
+	if rows := strings.Count(report, `<td class="number"`); rows != 1 {
+		t.Errorf("Expected 1 row in the largest files table, got %d", rows)
+	}
+	if strings.Contains(report, "<script src") || strings.Contains(report, `<link rel="stylesheet"`) {
+		t.Errorf("Expected a self-contained report")
+	}
+}
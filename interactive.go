@@ -0,0 +1,619 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// Width of the statistics panel next to the tree
+const panelWidth = 34
+
+// Terminals narrower than this show the tree without the panel
+const minPanelTermWidth = 80
+
+// browser holds the state of the interactive tree browser. It only deals
+// with keys and lines of text, so it can be driven without a terminal.
+type browser struct {
+	root      *Node
+	config    Config
+	treeChars TreeChars
+	parents   map[*Node]*Node
+	expanded  map[*Node]bool
+	typeSizes map[*Node][]typeSize
+
+	showFiles  bool
+	showHidden bool
+	sizeSort   bool
+
+	rows   []browserRow
+	cursor int
+	offset int
+
+	searching   bool
+	query       string
+	searchStart *Node
+
+	width  int
+	height int
+}
+
+// browserRow is one visible line of the tree
+type browserRow struct {
+	node   *Node
+	prefix string
+}
+
+// Scan the tree and browse it in the terminal until the user quits
+func runInteractive(config Config) error {
+	// Files and hidden entries are always scanned so they can be toggled on
+	scanConfig := config
+	scanConfig.ShowFiles = true
+	scanConfig.ShowHidden = true
+
+	stats := Stats{
+		FileTypes:  make(map[string]int64),
+		LargeFiles: []FileInfo{},
+	}
+	root, err := scanTree(scanConfig, &stats)
+	if isGitError(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error accessing path %s: %v", config.Path, err)
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer term.restore()
+
+	b := newBrowser(root, config, getTreeChars(config.Unicode, config.Compact))
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+
+	for {
+		b.width, b.height = term.size()
+		drawScreen(os.Stdout, b.view())
+
+		select {
+		case key, ok := <-keys:
+			if !ok || b.handleKey(key) {
+				return nil
+			}
+		case <-resized:
+		}
+	}
+}
+
+// Create a browser showing the root directory expanded
+func newBrowser(root *Node, config Config, treeChars TreeChars) *browser {
+	b := &browser{
+		root:       root,
+		config:     config,
+		treeChars:  treeChars,
+		parents:    make(map[*Node]*Node),
+		expanded:   map[*Node]bool{root: true},
+		typeSizes:  make(map[*Node][]typeSize),
+		showFiles:  config.ShowFiles,
+		showHidden: config.ShowHidden,
+		sizeSort:   config.Sort == "size",
+		width:      80,
+		height:     24,
+	}
+	b.indexParents(root)
+	b.refresh()
+	return b
+}
+
+// Record the parent of every node, for moving up the tree
+func (b *browser) indexParents(node *Node) {
+	for _, child := range node.Children {
+		b.parents[child] = node
+		b.indexParents(child)
+	}
+}
+
+// Rebuild the visible rows, keeping the selected node when it is still shown
+func (b *browser) refresh() {
+	selected := b.selected()
+
+	b.rows = append(b.rows[:0], browserRow{node: b.root})
+	b.addRows(b.root, "")
+
+	b.cursor = 0
+	for i, row := range b.rows {
+		if row.node == selected {
+			b.cursor = i
+			break
+		}
+	}
+}
+
+// Add the visible children of an expanded directory
+func (b *browser) addRows(node *Node, prefix string) {
+	children := b.visibleChildren(node)
+	for i, child := range children {
+		var branch strings.Builder
+		newPrefix, _ := writeBranch(&branch, b.treeChars, i == len(children)-1, prefix)
+		b.rows = append(b.rows, browserRow{node: child, prefix: branch.String()})
+
+		if child.IsDir() && b.expanded[child] {
+			b.addRows(child, newPrefix)
+		}
+	}
+}
+
+// Get the children shown with the current file and hidden toggles
+func (b *browser) visibleChildren(node *Node) []*Node {
+	var children []*Node
+	for _, child := range node.Children {
+		if b.isVisible(child) {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// Check whether a node passes the file and hidden toggles
+func (b *browser) isVisible(node *Node) bool {
+	if !b.showFiles && !node.IsDir() {
+		return false
+	}
+	if !b.showHidden && isHidden(node.Name) {
+		return false
+	}
+	return true
+}
+
+// Get the node under the cursor
+func (b *browser) selected() *Node {
+	if b.cursor < 0 || b.cursor >= len(b.rows) {
+		return nil
+	}
+	return b.rows[b.cursor].node
+}
+
+// Move the cursor, keeping it inside the rows
+func (b *browser) move(delta int) {
+	b.cursor += delta
+	if b.cursor >= len(b.rows) {
+		b.cursor = len(b.rows) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// Select a node, expanding its parents so that it is visible
+func (b *browser) reveal(node *Node) {
+	for parent := b.parents[node]; parent != nil; parent = b.parents[parent] {
+		b.expanded[parent] = true
+	}
+	b.refresh()
+	for i, row := range b.rows {
+		if row.node == node {
+			b.cursor = i
+			return
+		}
+	}
+}
+
+// Handle a key press. Returns true when the browser should exit.
+func (b *browser) handleKey(key string) bool {
+	if key == "ctrl-c" {
+		return true
+	}
+	if b.searching {
+		b.handleSearchKey(key)
+		return false
+	}
+
+	page := b.treeHeight() - 1
+	if page < 1 {
+		page = 1
+	}
+	node := b.selected()
+
+	switch key {
+	case "q":
+		return true
+	case "up", "k":
+		b.move(-1)
+	case "down", "j":
+		b.move(1)
+	case "pgup":
+		b.move(-page)
+	case "pgdn":
+		b.move(page)
+	case "home", "g":
+		b.cursor = 0
+	case "end", "G":
+		b.cursor = len(b.rows) - 1
+	case "right", "l":
+		if node.IsDir() {
+			if !b.expanded[node] {
+				b.expanded[node] = true
+				b.refresh()
+			} else if len(b.visibleChildren(node)) > 0 {
+				b.move(1)
+			}
+		}
+	case "left", "h":
+		if node.IsDir() && b.expanded[node] && node != b.root {
+			b.expanded[node] = false
+			b.refresh()
+		} else if parent := b.parents[node]; parent != nil {
+			b.reveal(parent)
+		}
+	case "enter", " ":
+		if node.IsDir() && node != b.root {
+			b.expanded[node] = !b.expanded[node]
+			b.refresh()
+		}
+	case "f":
+		b.showFiles = !b.showFiles
+		b.refresh()
+	case ".":
+		b.showHidden = !b.showHidden
+		b.refresh()
+	case "s":
+		b.sizeSort = !b.sizeSort
+		sortConfig := b.config
+		switch {
+		case b.sizeSort:
+			sortConfig.Sort = "size"
+		case b.config.Sort == "size":
+			sortConfig.Sort = "name"
+		}
+		sortTree(b.root, sortConfig)
+		b.refresh()
+	case "/":
+		b.searching = true
+		b.query = ""
+		b.searchStart = node
+	case "n":
+		b.search(1)
+	}
+	return false
+}
+
+// Handle a key press while typing a search
+func (b *browser) handleSearchKey(key string) {
+	switch key {
+	case "enter":
+		b.searching = false
+	case "esc":
+		b.searching = false
+		b.query = ""
+		b.reveal(b.searchStart)
+	case "backspace":
+		if b.query != "" {
+			_, size := utf8.DecodeLastRuneInString(b.query)
+			b.query = b.query[:len(b.query)-size]
+		}
+		b.reveal(b.searchStart)
+		b.search(0)
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			b.query += key
+			b.search(0)
+		}
+	}
+}
+
+// Select the next node whose name contains the query, searching collapsed
+// directories too. An offset of 0 allows the selected node to match.
+func (b *browser) search(offset int) {
+	if b.query == "" {
+		return
+	}
+
+	nodes := b.searchableNodes(b.root, nil)
+	start := 0
+	for i, node := range nodes {
+		if node == b.selected() {
+			start = i
+			break
+		}
+	}
+
+	query := strings.ToLower(b.query)
+	for i := 0; i < len(nodes); i++ {
+		node := nodes[(start+offset+i)%len(nodes)]
+		if strings.Contains(strings.ToLower(node.Name), query) {
+			b.reveal(node)
+			return
+		}
+	}
+}
+
+// List every node that could be shown, in tree order
+func (b *browser) searchableNodes(node *Node, nodes []*Node) []*Node {
+	nodes = append(nodes, node)
+	for _, child := range b.visibleChildren(node) {
+		nodes = b.searchableNodes(child, nodes)
+	}
+	return nodes
+}
+
+// Get the number of tree lines between the header and the status line
+func (b *browser) treeHeight() int {
+	return b.height - 2
+}
+
+// Render the whole screen as lines of text
+func (b *browser) view() []string {
+	treeWidth := b.width
+	showPanel := b.width >= minPanelTermWidth
+	if showPanel {
+		treeWidth = b.width - panelWidth - 1
+	}
+
+	// Scroll so the cursor stays visible
+	height := b.treeHeight()
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+height {
+		b.offset = b.cursor - height + 1
+	}
+
+	var panel []string
+	if showPanel {
+		panel = b.panel(height)
+	}
+
+	separator := "│"
+	if !b.config.Unicode {
+		separator = "|"
+	}
+
+	lines := []string{b.header()}
+	for i := 0; i < height; i++ {
+		line := strings.Repeat(" ", treeWidth)
+		if index := b.offset + i; index < len(b.rows) {
+			line = b.renderRow(b.rows[index], treeWidth, index == b.cursor)
+		}
+		if showPanel {
+			line += separator
+			if i < len(panel) {
+				line += fitText(panel[i], panelWidth)
+			}
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, b.statusLine())
+	return lines
+}
+
+// Render the title bar with the current toggles
+func (b *browser) header() string {
+	onOff := func(on bool) string {
+		if on {
+			return "on"
+		}
+		return "off"
+	}
+	sortMode := b.config.Sort
+	if b.sizeSort {
+		sortMode = "size"
+	} else if sortMode == "size" {
+		sortMode = "name"
+	}
+
+	text := fmt.Sprintf(" hyperion  %s   files: %s  hidden: %s  sort: %s",
+		b.config.Path, onOff(b.showFiles), onOff(b.showHidden), sortMode)
+	return "\x1b[7m" + fitText(text, b.width) + "\x1b[0m"
+}
+
+// Render the search prompt or the key help
+func (b *browser) statusLine() string {
+	if b.searching {
+		return fitText("/"+b.query, b.width)
+	}
+	return fitText(" ↑↓ move  ←→ collapse/expand  / search  n next  f files  . hidden  s size sort  q quit", b.width)
+}
+
+// Render one tree line with the entry size aligned on the right
+func (b *browser) renderRow(row browserRow, width int, selected bool) string {
+	node := row.node
+	marker := " "
+	if node.IsDir() && node != b.root {
+		marker = "+"
+		if b.expanded[node] {
+			marker = "-"
+		}
+		if len(node.Children) == 0 {
+			marker = " "
+		}
+	}
+
+	name := node.Name + linkNote(node)
+	if code := node.Git.code(); code != "" {
+		name = code + " " + name
+	}
+	name += errorNote(node)
+	size := " " + formatSize(node.Size) + " "
+
+	// Leave room for the size, cutting the name when needed
+	nameWidth := width - utf8.RuneCountInString(size) - utf8.RuneCountInString(row.prefix) - 2
+	if nameWidth < 1 {
+		nameWidth = 1
+	}
+	name = truncateText(name, nameWidth)
+	left := row.prefix + marker + " " + name
+	padding := width - utf8.RuneCountInString(left) - utf8.RuneCountInString(size)
+
+	if padding < 0 {
+		return fitText(left, width)
+	}
+	if selected {
+		return "\x1b[7m" + left + strings.Repeat(" ", padding) + size + "\x1b[0m"
+	}
+	if b.config.Color {
+		left = row.prefix + marker + " " + nodeColor(node, b.config.Theme).Sprint(name)
+	}
+	return left + strings.Repeat(" ", padding) + size
+}
+
+// Get the color of an entry, as the tree renderer colors it
+func nodeColor(node *Node, theme *Theme) *color.Color {
+	if node.Err != nil {
+		return newColor(color.FgRed)
+	}
+	return theme.color(node, false)
+}
+
+// Render the statistics of the selected directory
+func (b *browser) panel(height int) []string {
+	dir := b.selected()
+	if dir != nil && !dir.IsDir() {
+		dir = b.parents[dir]
+	}
+	if dir == nil {
+		return nil
+	}
+
+	lines := []string{
+		" " + dir.Name,
+		fmt.Sprintf(" Files: %d", dir.FileCount),
+		fmt.Sprintf(" Size:  %s", formatSize(dir.Size)),
+		"",
+		" Type          Size       %",
+	}
+	for _, info := range b.dirTypeSizes(dir) {
+		if len(lines) >= height {
+			break
+		}
+		percentage := 0.0
+		if dir.Size > 0 {
+			percentage = float64(info.Size) / float64(dir.Size) * 100
+		}
+		lines = append(lines, fmt.Sprintf(" %-12s %9s %5.1f", truncateText(info.Ext, 12), formatSize(info.Size), percentage))
+	}
+	return lines
+}
+
+// Get the size per file extension below a directory, largest first
+func (b *browser) dirTypeSizes(dir *Node) []typeSize {
+	if cached, ok := b.typeSizes[dir]; ok {
+		return cached
+	}
+
+	sizes := make(map[string]int64)
+	var collect func(node *Node)
+	collect = func(node *Node) {
+		for _, child := range node.Children {
+			if child.IsDir() {
+				collect(child)
+			} else {
+				sizes[fileGroup(child, b.config.GroupBy)] += child.Size
+			}
+		}
+	}
+	collect(dir)
+
+	typeInfos := fileTypeDistribution(Stats{FileTypes: sizes}, -1)
+
+	b.typeSizes[dir] = typeInfos
+	return typeInfos
+}
+
+// Cut text to a number of characters, marking the cut with "~"
+func truncateText(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:width-1]) + "~"
+}
+
+// Cut or pad text to exactly a number of characters
+func fitText(text string, width int) string {
+	text = truncateText(text, width)
+	return text + strings.Repeat(" ", width-utf8.RuneCountInString(text))
+}
+
+// Redraw the screen from the top left corner
+func drawScreen(w io.Writer, lines []string) {
+	fmt.Fprint(w, "\x1b[H"+strings.Join(lines, "\x1b[K\r\n")+"\x1b[K")
+}
+
+// Escape sequences sent by the keys the browser understands
+var escapeKeys = map[string]string{
+	"A":  "up",
+	"B":  "down",
+	"C":  "right",
+	"D":  "left",
+	"H":  "home",
+	"F":  "end",
+	"1~": "home",
+	"4~": "end",
+	"5~": "pgup",
+	"6~": "pgdn",
+}
+
+// Read key presses from the terminal until it is closed
+func readKeys(r io.Reader, keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		for _, key := range parseKeys(buf[:n]) {
+			keys <- key
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Split terminal input into key names
+func parseKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		switch data[0] {
+		case 0x1b:
+			if len(data) > 2 && (data[1] == '[' || data[1] == 'O') {
+				// Find the final byte of the escape sequence
+				end := 2
+				for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+					end++
+				}
+				if end == len(data) {
+					return append(keys, "esc")
+				}
+				if key, ok := escapeKeys[string(data[2:end+1])]; ok {
+					keys = append(keys, key)
+				}
+				data = data[end+1:]
+				continue
+			}
+			keys = append(keys, "esc")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		default:
+			if data[0] >= 0x20 {
+				r, size := utf8.DecodeRune(data)
+				keys = append(keys, string(r))
+				data = data[size:]
+				continue
+			}
+		}
+		data = data[1:]
+	}
+	return keys
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"j", []string{"j"}},
+		{"\x1b[A\x1b[B", []string{"up", "down"}},
+		{"\x1bOC", []string{"right"}},
+		{"\x1b[5~\x1b[6~", []string{"pgup", "pgdn"}},
+		{"\x1b", []string{"esc"}},
+		{"ab\r", []string{"a", "b", "enter"}},
+		{"\x7f\x03", []string{"backspace", "ctrl-c"}},
+		{"é", []string{"é"}},
+		{"\x1b[99~x", []string{"x"}},
+	}
+
+	for _, test := range tests {
+		result := parseKeys([]byte(test.input))
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("parseKeys(%q): expected %v, got %v", test.input, test.expected, result)
+		}
+	}
+}
+
+// newTestBrowser scans the test tree and opens a browser on it
+func newTestBrowser(t *testing.T) *browser {
+	t.Helper()
+
+	config := testConfig(createTestTree(t))
+	config.ShowFiles = false
+	scanConfig := config
+	scanConfig.ShowFiles = true
+	stats := newTestStats()
+	root, err := scanTree(scanConfig, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+	return newBrowser(root, config, getTreeChars(false, false))
+}
+
+// rowNames lists the names of the visible rows
+func rowNames(b *browser) []string {
+	var names []string
+	for _, row := range b.rows {
+		names = append(names, row.node.Name)
+	}
+	return names[1:]
+}
+
+func TestBrowserExpandCollapse(t *testing.T) {
+	b := newTestBrowser(t)
+
+	if expected := []string{"dir1", "dir2"}; !reflect.DeepEqual(rowNames(b), expected) {
+		t.Fatalf("Expected rows %v, got %v", expected, rowNames(b))
+	}
+
+	b.handleKey("down")
+	b.handleKey("right")
+	if expected := []string{"dir1", "subdir1", "subdir2", "dir2"}; !reflect.DeepEqual(rowNames(b), expected) {
+		t.Errorf("Expected rows %v after expanding dir1, got %v", expected, rowNames(b))
+	}
+
+	// Right again moves into the directory, left goes back to the parent
+	b.handleKey("right")
+	if b.selected().Name != "subdir1" {
+		t.Errorf("Expected subdir1 selected, got %s", b.selected().Name)
+	}
+	b.handleKey("left")
+	if b.selected().Name != "dir1" {
+		t.Errorf("Expected dir1 selected, got %s", b.selected().Name)
+	}
+	b.handleKey("left")
+	if expected := []string{"dir1", "dir2"}; !reflect.DeepEqual(rowNames(b), expected) {
+		t.Errorf("Expected rows %v after collapsing dir1, got %v", expected, rowNames(b))
+	}
+
+	b.handleKey("f")
+	if expected := []string{"dir1", "dir2", "file1.txt"}; !reflect.DeepEqual(rowNames(b), expected) {
+		t.Errorf("Expected rows %v with files, got %v", expected, rowNames(b))
+	}
+
+	if !b.handleKey("q") {
+		t.Errorf("Expected q to quit")
+	}
+}
+
+func TestBrowserSearch(t *testing.T) {
+	b := newTestBrowser(t)
+	b.handleKey("f")
+
+	for _, key := range []string{"/", "f", "i", "l", "e", "4"} {
+		b.handleKey(key)
+	}
+	if b.selected().Name != "file4.txt" {
+		t.Fatalf("Expected file4.txt selected, got %s", b.selected().Name)
+	}
+	if !b.expanded[b.parents[b.selected()]] {
+		t.Errorf("Expected the parents of the match to be expanded")
+	}
+
+	// Escape returns to where the search started
+	b.handleKey("esc")
+	if b.selected() != b.root {
+		t.Errorf("Expected the root selected after escape, got %s", b.selected().Name)
+	}
+
+	for _, key := range []string{"/", "t", "x", "t", "enter"} {
+		b.handleKey(key)
+	}
+	first := b.selected().Name
+	b.handleKey("n")
+	if second := b.selected().Name; second == first {
+		t.Errorf("Expected n to move to the next match after %s", first)
+	}
+}
+
+func TestBrowserView(t *testing.T) {
+	b := newTestBrowser(t)
+	b.width, b.height = 100, 10
+
+	lines := b.view()
+	if len(lines) != 10 {
+		t.Fatalf("Expected 10 lines, got %d", len(lines))
+	}
+	if !strings.Contains(lines[2], "dir1") || !strings.Contains(lines[2], "│") {
+		t.Errorf("Expected dir1 and the panel separator on line 2, got %q", lines[2])
+	}
+	if !strings.Contains(strings.Join(lines, "\n"), "Files: 3") {
+		t.Errorf("Expected the panel to show the file count")
+	}
+
+	// Narrow terminals drop the panel
+	b.width = 40
+	for _, line := range b.view()[1:9] {
+		if strings.Contains(line, "\x1b") {
+			continue
+		}
+		if width := utf8.RuneCountInString(line); width != 40 {
+			t.Errorf("Expected lines of 40 characters, got %d: %q", width, line)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// jsonNode is the JSON representation of a tree node
+type jsonNode struct {
+	Name      string       `json:"name"`
+	Type      string       `json:"type"`
+	Size      int64        `json:"size"`
+	Files     int          `json:"files,omitempty"`
+	Mode      string       `json:"mode"`
+	ModTime   time.Time    `json:"mtime"`
+	Target    string       `json:"target,omitempty"`
+	Broken    bool         `json:"broken,omitempty"`
+	Recursive bool         `json:"recursive,omitempty"`
+	Git       []string     `json:"git,omitempty"`
+	History   *jsonHistory `json:"history,omitempty"`
+	Language  string       `json:"language,omitempty"`
+	Category  string       `json:"category,omitempty"`
+	Hash      string       `json:"sha256,omitempty"`
+	Collapsed bool         `json:"collapsed,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Children  []*jsonNode  `json:"children,omitempty"`
+}
+
+// jsonHistory is the git history of a node
+type jsonHistory struct {
+	LastCommit time.Time `json:"last_commit"`
+	Author     string    `json:"author"`
+	Commits    int       `json:"commits"`
+}
+
+// jsonReport is the top-level JSON document
+type jsonReport struct {
+	Root  *jsonNode `json:"root"`
+	Stats Stats     `json:"stats"`
+}
+
+// Write the tree and statistics as one JSON document
+func writeJSON(w io.Writer, root *Node, stats Stats, config Config) error {
+	// Keep only the largest files, as the stat table does
+	sort.SliceStable(stats.LargeFiles, func(i, j int) bool {
+		return stats.LargeFiles[i].Size > stats.LargeFiles[j].Size
+	})
+	if config.StatsCount >= 0 && len(stats.LargeFiles) > config.StatsCount {
+		stats.LargeFiles = stats.LargeFiles[:config.StatsCount]
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonReport{
+		Root:  toJSONNode(root),
+		Stats: stats,
+	})
+}
+
+// Convert a tree node and its children to the JSON representation
+func toJSONNode(node *Node) *jsonNode {
+	jn := &jsonNode{
+		Name:      node.Name,
+		Type:      nodeType(node),
+		Size:      node.Size,
+		Files:     node.FileCount,
+		Mode:      node.Mode.String(),
+		ModTime:   node.ModTime,
+		Target:    node.LinkTarget,
+		Broken:    node.LinkBroken,
+		Recursive: node.LinkLoop,
+		Git:       node.Git.names(),
+		Language:  node.FileType.Language,
+		Category:  node.FileType.Category,
+		Hash:      node.Hash,
+		Collapsed: node.Collapsed,
+	}
+	if node.History != nil {
+		jn.History = &jsonHistory{
+			LastCommit: node.History.LastCommit,
+			Author:     node.History.Author,
+			Commits:    node.History.Commits,
+		}
+	}
+	if node.Err != nil {
+		jn.Error = node.Err.Error()
+	}
+	for _, child := range node.Children {
+		jn.Children = append(jn.Children, toJSONNode(child))
+	}
+	return jn
+}
+
+// Get a short type name for a node
+func nodeType(node *Node) string {
+	switch {
+	case node.IsDir():
+		return "directory"
+	case node.IsSymlink():
+		return "symlink"
+	case node.Mode.IsRegular():
+		return "file"
+	default:
+		return "other"
+	}
+}
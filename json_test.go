@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	config.Output = "json"
+	config.StatsCount = 2
+	stats := newTestStats()
+
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, root, stats, config); err != nil {
+		t.Fatalf("writeJSON failed: %v", err)
+	}
+
+	var report struct {
+		Root struct {
+			Name     string `json:"name"`
+			Type     string `json:"type"`
+			Children []struct {
+				Name string `json:"name"`
+				Type string `json:"type"`
+				Size int64  `json:"size"`
+			} `json:"children"`
+		} `json:"root"`
+		Stats struct {
+			TotalDirs  int
+			TotalFiles int
+			FileTypes  map[string]int64
+			LargeFiles []FileInfo
+		} `json:"stats"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+
+	if report.Root.Type != "directory" {
+		t.Errorf("Expected root type %q, got %q", "directory", report.Root.Type)
+	}
+
+	if len(report.Root.Children) != 3 {
+		t.Fatalf("Expected 3 root children, got %d", len(report.Root.Children))
+	}
+
+	file := report.Root.Children[2]
+	if file.Name != "file1.txt" || file.Type != "file" || file.Size == 0 {
+		t.Errorf("Unexpected file entry: %+v", file)
+	}
+
+	if report.Stats.TotalDirs != 4 || report.Stats.TotalFiles != 3 {
+		t.Errorf("Expected 4 dirs and 3 files, got %d and %d", report.Stats.TotalDirs, report.Stats.TotalFiles)
+	}
+
+	if len(report.Stats.LargeFiles) != 2 {
+		t.Errorf("Expected LargeFiles limited to 2 entries, got %d", len(report.Stats.LargeFiles))
+	}
+}
+
+func TestNodeType(t *testing.T) {
+	tests := []struct {
+		node     *Node
+		expected string
+	}{
+		{&Node{Mode: 0644}, "file"},
+		{&Node{Mode: fs.ModeDir | 0755}, "directory"},
+		{&Node{Mode: fs.ModeSymlink | 0777}, "symlink"},
+	}
+
+	for _, test := range tests {
+		result := nodeType(test.node)
+		if result != test.expected {
+			t.Errorf("nodeType(%v): expected %q, got %q", test.node.Mode, test.expected, result)
+		}
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -25,20 +24,53 @@ type Config struct {
 	Unicode        bool
 	Color          bool
 	BgColor        bool
+	Theme          *Theme
 	Compact        bool
 	ShowStats      bool
 	StatTable      bool
 	StatsCount     int
 	Chart          bool
+	Output         string
+	Jobs           int
+	Gitignore      bool
+	ShowHidden     bool
+	CollapseVCS    bool
+	FollowSymlinks bool
+	Strict         bool
+	Git            bool
+	GitAge         bool
+	Include        []string
+	Exclude        []string
+	IncludeRegex   []string
+	ExcludeRegex   []string
+	Sort           string
+	GroupBy        string
+	Reverse        bool
+	DirOrder       string
+	ShowSize       bool
+	ShowDirSize    bool
+	ShowPerms      bool
+	ShowOwner      bool
+	ShowMtime      bool
+	ShowInode      bool
+	DiskUsage      bool
+	FindDuplicates bool
+	Interactive    bool
+	Watch          bool
+	WatchLog       bool
+	MarkdownList   bool
 }
 
 // Statistics structure to track directory stats
 type Stats struct {
-	TotalDirs  int
-	TotalFiles int
-	TotalSize  int64
-	FileTypes  map[string]int64
-	LargeFiles []FileInfo
+	TotalDirs   int
+	TotalFiles  int
+	TotalSize   int64
+	FileTypes   map[string]int64
+	LargeFiles  []FileInfo
+	Errors      []ScanError   `json:",omitempty"`
+	MostChanged []PathHistory `json:",omitempty"`
+	Oldest      []PathHistory `json:",omitempty"`
 }
 
 // FileInfo to track file stats for the largest files
@@ -59,31 +91,29 @@ type TreeChars struct {
 
 // Main function
 func main() {
+	// Dispatch subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "snapshot":
+			os.Exit(runSnapshot(os.Args[2:]))
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		}
+	}
+
 	// Define and parse command line flags
-	var config Config
-	var excludeFoldersStr, excludeFilesStr, excludeNamesStr string
-
-	flag.StringVar(&config.Path, "path", ".", "Root directory to scan")
-	flag.StringVar(&excludeFoldersStr, "exclude-folders", "node_modules", "Folders to exclude from tree (comma-separated)")
-	flag.BoolVar(&config.ShowFiles, "show-files", false, "Whether to show files in output")
-	flag.StringVar(&excludeFilesStr, "exclude-files", "", "File extensions to exclude (comma-separated, e.g., '.exe,.dll')")
-	flag.StringVar(&excludeNamesStr, "exclude-names", "", "File names to exclude exactly (comma-separated, e.g., 'config.json,README.md')")
-	flag.IntVar(&config.MaxDepth, "max-depth", -1, "Maximum depth to recurse (-1 for unlimited)")
-	flag.BoolVar(&config.Unicode, "unicode", true, "Use Unicode characters for pretty tree visuals")
-	flag.BoolVar(&config.Color, "color", true, "Use colors in output")
-	flag.BoolVar(&config.BgColor, "bg-color", false, "Use background color for items")
-	flag.BoolVar(&config.Compact, "compact", false, "Enable compact tree layout")
-	flag.BoolVar(&config.ShowStats, "show-stats", false, "Show total files, dirs, size")
-	flag.BoolVar(&config.StatTable, "stat-table", false, "Show a table of largest files and types")
-	flag.IntVar(&config.StatsCount, "stats-count", 10, "Number of top files to show in stats table")
-	flag.BoolVar(&config.Chart, "chart", false, "Show a visual chart of file size distribution")
-	
+	flags := registerConfigFlags(flag.CommandLine)
+
 	// Check for help flag
 	helpFlag    := flag.Bool("help", false, "Show usage and examples")
 	aboutFlag   := flag.Bool("about", false, "Show about the software")
 	versionFlag := flag.Bool("version", false, "Show version information")
 
 	flag.Parse()
+	if err := flags.checkNoArgs(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
 
 	if *helpFlag {
 		showHelp()
@@ -100,18 +130,31 @@ func main() {
 		return
 	}
 
-	// Process comma-separated values into slices
-	config.ExcludeFolders = splitCommaString(excludeFoldersStr)
-	config.ExcludeFiles   = splitCommaString(excludeFilesStr)
-	config.ExcludeNames   = splitCommaString(excludeNamesStr)
+	config, err := flags.resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	// The color package follows --color rather than its own terminal check
+	color.NoColor = !config.Color
 
-	// Auto-detect Unicode support if needed
-	if runtime.GOOS == "windows" && config.Unicode {
-		unicodeSupported := isTerminalSupportsUnicode()
-		if !unicodeSupported {
-			fmt.Println("Note: Unicode characters may not display correctly in this terminal.")
-			fmt.Println("      Use --unicode=false for ASCII characters instead.")
+	// Browse the tree in the terminal instead of printing it
+	if config.Interactive {
+		if err := runInteractive(config); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitFailure)
 		}
+		return
+	}
+
+	// Re-render or log changes as the directory changes
+	if config.Watch {
+		if err := runWatch(config); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitFailure)
+		}
+		return
 	}
 
 	// Initialize statistics
@@ -120,38 +163,78 @@ func main() {
 		LargeFiles: []FileInfo{},
 	}
 
-	// Select tree characters based on Unicode flag
-	treeChars := getTreeChars(config.Unicode, config.Compact)
-
-	// Print the root directory
-	rootInfo, err := os.Stat(config.Path)
+	// Scan the directory tree
+	root, err := scanTree(config, &stats)
+	if isGitError(err) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
 	if err != nil {
-		fmt.Printf("Error accessing path %s: %v\n", config.Path, err)
-		return
+		fmt.Fprintf(os.Stderr, "Error accessing path %s: %v\n", config.Path, err)
+		os.Exit(exitPathError)
 	}
 
-	rootDir := filepath.Base(config.Path)
-	
-	// Print root directory with appropriate styling
-	if config.Color {
-		if config.BgColor {
-			color.New(color.FgHiWhite, color.BgBlue).Printf("%s\n", rootDir)
-		} else {
-			color.New(color.FgBlue, color.Bold).Printf("%s\n", rootDir)
+	if err := printOutput(root, stats, config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(exitFailure)
+	}
+
+	// Entries that could not be read are marked in the tree and listed
+	// after it, away from the output
+	writeErrorSummary(os.Stderr, stats.Errors)
+	if config.Strict && len(stats.Errors) > 0 {
+		os.Exit(exitUnreadable)
+	}
+}
+
+// Print the scanned tree, or the report selected by the flags
+func printOutput(root *Node, stats Stats, config Config) error {
+	// Report duplicate files instead of the tree
+	if config.FindDuplicates {
+		groups := findDuplicates(root, config.Jobs)
+		if config.Output == "json" {
+			return writeDuplicatesJSON(os.Stdout, groups)
 		}
-	} else {
-		fmt.Printf("%s\n", rootDir)
+		printDuplicates(os.Stdout, groups)
+		if config.ShowStats || config.StatTable || config.Chart {
+			printStats(config, stats)
+		}
+		return nil
+	}
+
+	// Emit a JSON document instead of the tree
+	if config.Output == "json" {
+		return writeJSON(os.Stdout, root, stats, config)
+	}
+
+	// Emit a self-contained HTML report
+	if config.Output == "html" {
+		return writeHTML(os.Stdout, root, stats, config)
 	}
 
-	// Walk the directory tree
-	if rootInfo.IsDir() {
-		walkDir(config, config.Path, "", "", 0, treeChars, &stats)
+	// Emit Markdown for documentation
+	if config.Output == "markdown" {
+		return writeMarkdown(os.Stdout, root, stats, config)
+	}
+
+	// Select tree characters based on Unicode flag
+	treeChars := getTreeChars(config.Unicode, config.Compact)
+
+	// Render the directory tree
+	renderer := GetRenderer(color.Output, config, treeChars)
+	renderer.RenderRoot(root)
+	if root.IsDir() {
+		walkDir(renderer, root, "")
 	}
 
 	// Show statistics if requested
 	if config.ShowStats || config.StatTable || config.Chart {
 		printStats(config, stats)
 	}
+	if config.GitAge {
+		printHistory(os.Stdout, config, stats)
+	}
+	return nil
 }
 
 // Function for version display
@@ -167,6 +250,8 @@ func showHelp() {
 
 	Usage:
 	hyperion [flags]
+	hyperion snapshot [flags] [--out file]
+	hyperion diff [flags] [--unchanged] <old snapshot|path> <new snapshot|path>
 
 	Flags:
 	--path string             Root directory to scan (default ".")
@@ -174,19 +259,66 @@ func showHelp() {
 	--show-files              Whether to show files in output (default false)
 	--exclude-files string    File extensions to exclude (e.g., ".exe,.dll")
 	--exclude-names string    File names to exclude exactly (e.g., "config.json,README.md")
+	--gitignore               Skip entries ignored by .gitignore files and .git/info/exclude (default false)
+	--all, --hidden           Show hidden entries whose names start with a dot (default false)
+	--collapse-vcs            Show .git, .svn and .hg directories as single entries with their total size (default false)
+	--include string          Only show files matching these globs (comma-separated, repeatable)
+	--exclude string          Exclude files and folders matching these globs (comma-separated, repeatable)
+	--include-regex string    Only show files whose relative path matches this regex (repeatable)
+	--exclude-regex string    Exclude files and folders whose relative path matches this regex (repeatable)
 	--max-depth int           Maximum depth to recurse (-1 for unlimited) (default -1)
-	--unicode                 Use Unicode characters for pretty tree visuals (default true)
-	--color                   Use colors in output (default true)
+	--follow-symlinks         Descend into symlinked directories, skipping links that loop back (default false)
+	--sort string             Sort entries by name, size, mtime, ext or natural (default "name")
+	--reverse                 Reverse the sort order (default false)
+	--dirs-first              List directories before files (default)
+	--dirs-last               List directories after files
+	--mixed                   Sort directories and files together
+	--charset string          Tree characters: auto, unicode or ascii; auto follows LC_ALL, LC_CTYPE and LANG (default "auto")
+	--unicode                 Same as --charset=unicode, or --charset=ascii with --unicode=false (default true)
+	--color string            Use colors: auto, always or never; auto honors NO_COLOR, CLICOLOR_FORCE and the terminal (default "auto")
 	--bg-color                Use background color for items (default false)
+	--theme string            Color theme: dark, light, ls or a theme file (default LS_COLORS if set, else dark)
 	--compact                 Enable compact tree layout (default false)
+	--show-size               Show the size of each file (default false)
+	--show-dir-size           Show the cumulative size of each directory (default false)
+	--show-perms              Show the permissions of each entry (default false)
+	--show-owner              Show the owner and group of each entry (default false)
+	--show-mtime              Show the modification time of each entry (default false)
+	--show-inode              Show the inode number of each entry (default false)
+	--git                     Mark entries with their git status: modified, staged, untracked, ignored or conflicted (default false)
+	--git-age                 Show the last commit date, author and commit count of each entry, and the most changed and oldest paths (default false)
+	--du                      Disk usage mode: show directory sizes with bars and percent of parent (default false)
+	--interactive             Browse the tree in a full-screen terminal view (default false)
+	--watch                   Re-render the tree when the directory changes (Linux only) (default false)
+	--watch-log               Print created, deleted, renamed and modified entries instead of re-rendering (default false)
+	--find-duplicates         Report groups of files with identical content instead of the tree (default false)
 	--show-stats              Show total files, dirs, size (default false)
 	--stat-table              Show a table of largest files and types (default false)
 	--stats-count int         Number of top files to show in stats table (default 10)
+	--group-by string         Group the file type statistics by ext, language or category (default "ext")
 	--chart                   Show a visual chart of file size distribution (default false)
+	--output string           Output format: text, json, html or markdown (default "text")
+	--markdown-list           With --output markdown, show the tree as a nested list with links (default false)
+	--jobs int                Number of directories to read in parallel (0 for one per CPU) (default 1)
+	--profile string          Apply a named profile from the configuration file
+	--strict                  Exit with status 4 when any entry cannot be read (default false)
 	--help                    Show usage and examples
 	--about                   Show about
 	--version                 Show version
 
+	Subcommands:
+	snapshot                  Save the tree with sizes, mtimes and SHA-256 hashes as JSON
+	  --out string            File to write the snapshot to (default stdout)
+	diff                      Compare two snapshots, or a snapshot and a directory
+	  --unchanged             Also show entries that did not change (default false)
+
+	Exit codes:
+	0                         Success, including trees with unreadable entries
+	1                         The output could not be written
+	2                         Invalid flags or configuration
+	3                         The path to scan does not exist or cannot be read
+	4                         Some entries could not be read and --strict was given
+
 	Examples:
 	# Basic usage (folders only)
 	hyperion
@@ -194,14 +326,78 @@ func showHelp() {
 	# Exclude folders and file types
 	hyperion --show-files --exclude-folders "bin,obj" --exclude-files ".exe,.dll"
 
+	# Show only what git tracks or would track
+	hyperion --show-files --gitignore --exclude-folders ""
+
+	# Go sources without tests, skipping build output and vendored test data
+	hyperion --show-files --include "*.go" --exclude "*_test.go,build-*,vendor/**/testdata"
+
 	# Show stats with Unicode and color
-	hyperion --show-files --unicode --color --show-stats
+	hyperion --show-files --charset unicode --color=always --show-stats
+
+	# Include dotfiles, with .git shown as a single entry and its size
+	hyperion --show-files --all --collapse-vcs
+
+	# List the contents of symlinked directories too
+	hyperion --show-files --follow-symlinks
+
+	# What changed on this branch, rolled up to directories
+	hyperion --show-files --git
+
+	# Find hot spots and stale corners of a repository
+	hyperion --show-files --git-age --stats-count 5
+
+	# Biggest subtrees first, directories and files mixed
+	hyperion --show-files --sort size --mixed
+
+	# Where the bytes go: source, images, archives, binaries...
+	hyperion --show-stats --chart --group-by category
 
 	# Show top 15 largest files with chart
 	hyperion --show-files --stat-table --stats-count 15 --chart
 
 	# Compact view with background color
 	hyperion --show-files --compact --bg-color
+
+	# Colors for a light terminal background
+	hyperion --show-files --theme light
+
+	# Biggest directories two levels deep, like du -sh * | sort -h
+	hyperion --du --max-depth 1
+
+	# Sizes, permissions and owners next to each entry, like tree -h -p -u
+	hyperion --show-files --show-size --show-dir-size --show-perms --show-owner
+
+	# Browse a large tree, expanding directories as needed
+	hyperion --interactive --path /mnt/monorepo --jobs 0
+
+	# Follow an upload staging folder as files arrive
+	hyperion --path /srv/staging --watch-log
+
+	# Duplicate files in the build output, biggest savings first
+	hyperion --path dist --find-duplicates --jobs 0
+
+	# Full tree and statistics as JSON
+	hyperion --show-files --output json > tree.json
+
+	# Tree and statistics tables to paste into a README
+	hyperion --path docs --show-files --show-stats --output markdown
+
+	# Self-contained HTML report to attach to CI artifacts
+	hyperion --path dist --show-files --stats-count 20 --output html > report.html
+
+	# Record a build and compare it with the next one
+	hyperion snapshot --path dist --out dist-1.0.json
+	hyperion diff dist-1.0.json dist
+
+	# Fail a CI step when part of the tree cannot be read
+	hyperion --path /srv/data --show-files --strict > tree.txt
+
+	# Settings from the ci profile of .hyperion.yaml (flags > HYPERION_* variables > profile > file)
+	hyperion --profile ci
+
+	# Scan a large network mount with 16 parallel readers
+	hyperion --path /mnt/monorepo --jobs 16 --show-stats
 	`
 	fmt.Println(helpText)
 }
@@ -296,139 +492,23 @@ func getTreeChars(useUnicode bool, compact bool) TreeChars {
 	}
 }
 
-// Walk directory tree recursively
-func walkDir(config Config, path string, prefix string, lastPrefix string, depth int, treeChars TreeChars, stats *Stats) {
-	// Check max depth
-	if config.MaxDepth != -1 && depth > config.MaxDepth {
-		return
-	}
-
-	// Read directory entries
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		fmt.Printf("Error reading directory %s: %v\n", path, err)
-		return
-	}
-
-	// Filter and sort entries
-	var dirs, files []fs.DirEntry
-	for _, entry := range entries {
-		name := entry.Name()
-		if entry.IsDir() {
-			// Check if folder should be excluded
-			if !shouldExcludeFolder(name, config.ExcludeFolders) {
-				dirs = append(dirs, entry)
-			}
-		} else if config.ShowFiles {
-			// Check if file should be excluded by extension or name
-			if !shouldExcludeFile(name, config.ExcludeFiles, config.ExcludeNames) {
-				files = append(files, entry)
-			}
-		}
-	}
-
-	// Process directories
-	for i, entry := range dirs {
-		isLast := i == len(dirs)-1 && len(files) == 0
+// Walk the scanned tree and render its entries with the given renderer
+func walkDir(r Renderer, node *Node, prefix string) {
+	for i, child := range node.Children {
+		isLast := i == len(node.Children)-1
 
-		entryPath := filepath.Join(path, entry.Name())
-		newPrefix, newLastPrefix := renderDir(entry.Name(), isLast, prefix, config, treeChars)
-
-		stats.TotalDirs++
-		walkDir(config, entryPath, newPrefix, newLastPrefix, depth+1, treeChars, stats)
-	}
-
-	// Process files
-	for i, entry := range files {
-		isLast := i == len(files)-1
-
-		entryPath := filepath.Join(path, entry.Name())
-		info, err := entry.Info()
-		if err != nil {
-			fmt.Printf("Error getting file info for %s: %v\n", entryPath, err)
+		if child.IsDir() {
+			newPrefix, _ := r.RenderDir(child, isLast, prefix)
+			walkDir(r, child, newPrefix)
 			continue
 		}
 
-		fileSize := info.Size()
-		fileExt := getFileExtension(entry.Name())
-		
-		// Update statistics
-		stats.TotalFiles++
-		stats.TotalSize += fileSize
-		stats.FileTypes[fileExt] += fileSize
-		
-		// Track large files for stat table
-		if config.StatTable {
-			stats.LargeFiles = append(stats.LargeFiles, FileInfo{
-				Path: entryPath,
-				Size: fileSize,
-				Type: fileExt,
-			})
+		if child.Err != nil {
+			r.RenderError(child, isLast, prefix)
+			continue
 		}
 
-		// Determine if the file is a symlink
-		isSymlink := info.Mode()&os.ModeSymlink != 0
-		
-		// Render the file
-		renderFile(entry.Name(), isLast, prefix, isSymlink, config, treeChars)
-	}
-}
-
-// Render directory with appropriate styling
-func renderDir(name string, isLast bool, prefix string, config Config, treeChars TreeChars) (string, string) {
-	var newPrefix, newLastPrefix string
-	
-	if isLast {
-		fmt.Print(prefix)
-		fmt.Print(treeChars.LastItem)
-		newPrefix = prefix + treeChars.Indent
-		newLastPrefix = prefix + treeChars.Indent
-	} else {
-		fmt.Print(prefix)
-		fmt.Print(treeChars.MiddleItem)
-		newPrefix = prefix + treeChars.Line
-		newLastPrefix = prefix + treeChars.Indent
-	}
-
-	if config.Color {
-		if config.BgColor {
-			color.New(color.FgHiWhite, color.BgBlue).Printf("%s\n", name)
-		} else {
-			color.New(color.FgBlue, color.Bold).Printf("%s\n", name)
-		}
-	} else {
-		fmt.Printf("%s\n", name)
-	}
-	
-	return newPrefix, newLastPrefix
-}
-
-// Render file with appropriate styling
-func renderFile(name string, isLast bool, prefix string, isSymlink bool, config Config, treeChars TreeChars) {
-	if isLast {
-		fmt.Print(prefix)
-		fmt.Print(treeChars.LastItem)
-	} else {
-		fmt.Print(prefix)
-		fmt.Print(treeChars.MiddleItem)
-	}
-
-	if config.Color {
-		if isSymlink {
-			if config.BgColor {
-				color.New(color.FgHiWhite, color.BgMagenta).Printf("%s\n", name)
-			} else {
-				color.New(color.FgMagenta).Printf("%s\n", name)
-			}
-		} else {
-			if config.BgColor {
-				color.New(color.FgHiWhite, color.BgGreen).Printf("%s\n", name)
-			} else {
-				color.New(color.FgGreen).Printf("%s\n", name)
-			}
-		}
-	} else {
-		fmt.Printf("%s\n", name)
+		r.RenderFile(child, isLast, prefix)
 	}
 }
 
@@ -464,6 +544,10 @@ func shouldExcludeFile(name string, excludeFiles, excludeNames []string) bool {
 // Get file extension in lowercase (with dot)
 func getFileExtension(path string) string {
 	ext := strings.ToLower(filepath.Ext(path))
+	// Dotfiles such as ".hidden" have no extension
+	if ext == strings.ToLower(filepath.Base(path)) {
+		return ""
+	}
 	return ext
 }
 
@@ -526,24 +610,8 @@ func printStats(config Config, stats Stats) {
 	if len(stats.FileTypes) > 0 {
 		fmt.Println("\n🗂️ File Type Distribution:")
 		
-		// Convert map to slice for sorting
-		type TypeInfo struct {
-			Ext  string
-			Size int64
-		}
-		
-		typeInfos := make([]TypeInfo, 0, len(stats.FileTypes))
-		for ext, size := range stats.FileTypes {
-			if ext == "" {
-				ext = "(no extension)"
-			}
-			typeInfos = append(typeInfos, TypeInfo{ext, size})
-		}
-		
-		// Sort by size
-		sort.Slice(typeInfos, func(i, j int) bool {
-			return typeInfos[i].Size > typeInfos[j].Size
-		})
+		// Largest types first, in the same order as the chart
+		typeInfos := fileTypeDistribution(stats, -1)
 		
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
 		fmt.Fprintln(w, "  Type\tSize\tPercentage\t")
@@ -561,7 +629,7 @@ func printStats(config Config, stats Stats) {
 
 	// Print chart if requested
 	if config.Chart && len(stats.FileTypes) > 0 {
-		printChart(stats, config)
+		fmt.Print(getChartRenderer(config).renderChart(stats, config))
 	}
 }
 
@@ -580,136 +648,3 @@ func formatSize(size int64) string {
 	
 	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
 }
-
-// Print ASCII/Unicode chart of file type distribution
-func printChart(stats Stats, config Config) {
-	if config.Unicode {
-		printUnicodeChart(stats, config)
-	} else {
-		printAsciiChart(stats, config)
-	}
-}
-
-// Print chart with Unicode block characters
-func printUnicodeChart(stats Stats, config Config) {
-	fmt.Println("\n📊 File Size Distribution Chart:")
-	
-	// Convert map to slice for sorting
-	type TypeInfo struct {
-		Ext  string
-		Size int64
-	}
-	
-	typeInfos := make([]TypeInfo, 0, len(stats.FileTypes))
-	for ext, size := range stats.FileTypes {
-		if ext == "" {
-			ext = "(no extension)"
-		}
-		typeInfos = append(typeInfos, TypeInfo{ext, size})
-	}
-	
-	// Sort by size
-	sort.Slice(typeInfos, func(i, j int) bool {
-		return typeInfos[i].Size > typeInfos[j].Size
-	})
-	
-	// Limit to top items for the chart
-	limit := config.StatsCount
-	if limit > len(typeInfos) {
-		limit = len(typeInfos)
-	}
-	
-	typeInfos = typeInfos[:limit]
-	
-	// Find the maximum size for scaling
-	maxSize := int64(0)
-	for _, info := range typeInfos {
-		if info.Size > maxSize {
-			maxSize = info.Size
-		}
-	}
-	
-	// Maximum bar width
-	const maxWidth = 50
-	
-	// Print the chart
-	for _, info := range typeInfos {
-		// Calculate bar width proportional to size
-		width := int(float64(info.Size) / float64(maxSize) * float64(maxWidth))
-		if width < 1 {
-			width = 1
-		}
-		
-		// Print the bar
-		bar := strings.Repeat("█", width)
-		percentage := float64(info.Size) / float64(stats.TotalSize) * 100
-		
-		fmt.Printf("  %-15s [%-50s] %6.1f%% (%s)\n", 
-			info.Ext, 
-			bar, 
-			percentage, 
-			formatSize(info.Size))
-	}
-}
-
-// Print chart with ASCII characters
-func printAsciiChart(stats Stats, config Config) {
-	fmt.Println("\n# File Size Distribution Chart:")
-	
-	// Convert map to slice for sorting
-	type TypeInfo struct {
-		Ext  string
-		Size int64
-	}
-	
-	typeInfos := make([]TypeInfo, 0, len(stats.FileTypes))
-	for ext, size := range stats.FileTypes {
-		if ext == "" {
-			ext = "(no extension)"
-		}
-		typeInfos = append(typeInfos, TypeInfo{ext, size})
-	}
-	
-	// Sort by size
-	sort.Slice(typeInfos, func(i, j int) bool {
-		return typeInfos[i].Size > typeInfos[j].Size
-	})
-	
-	// Limit to top items for the chart
-	limit := config.StatsCount
-	if limit > len(typeInfos) {
-		limit = len(typeInfos)
-	}
-	
-	typeInfos = typeInfos[:limit]
-	
-	// Find the maximum size for scaling
-	maxSize := int64(0)
-	for _, info := range typeInfos {
-		if info.Size > maxSize {
-			maxSize = info.Size
-		}
-	}
-	
-	// Maximum bar width
-	const maxWidth = 50
-	
-	// Print the chart
-	for _, info := range typeInfos {
-		// Calculate bar width proportional to size
-		width := int(float64(info.Size) / float64(maxSize) * float64(maxWidth))
-		if width < 1 {
-			width = 1
-		}
-		
-		// Print the bar
-		bar := strings.Repeat("#", width)
-		percentage := float64(info.Size) / float64(stats.TotalSize) * 100
-		
-		fmt.Printf("  %-15s [%-50s] %6.1f%% (%s)\n", 
-			info.Ext, 
-			bar, 
-			percentage, 
-			formatSize(info.Size))
-	}
-}
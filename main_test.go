@@ -2,8 +2,6 @@ package main
 
 import (
 	"testing"
-	"os"
-	"path/filepath"
 	"strings"
 )
 
@@ -76,91 +74,3 @@ func TestFormatSize(t *testing.T) {
 		}
 	}
 }
-
-// TestWalkDir is a more complex test that requires setting up a temporary directory structure
-func TestWalkDir(t *testing.T) {
-	// Create temporary directory structure
-	tempDir, err := os.MkdirTemp("", "hyperion-test")
-	if err != nil {
-		t.Fatalf("Failed to create temp directory: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	// Create test directory structure
-	dirs := []string{
-		"dir1",
-		"dir1/subdir1",
-		"dir1/subdir2",
-		"dir2",
-		"node_modules", // This should be excluded by default
-	}
-
-	files := []string{
-		"file1.txt",
-		"file2.exe", // This could be excluded by extension
-		"dir1/file3.txt",
-		"dir1/subdir1/file4.txt",
-		"dir2/README.md", // This could be excluded by name
-	}
-
-	// Create directories
-	for _, dir := range dirs {
-		err := os.MkdirAll(filepath.Join(tempDir, dir), 0755)
-		if err != nil {
-			t.Fatalf("Failed to create directory %s: %v", dir, err)
-		}
-	}
-
-	// Create files with some content
-	for _, file := range files {
-		content := []byte("Test content for " + file)
-		err := os.WriteFile(filepath.Join(tempDir, file), content, 0644)
-		if err != nil {
-			t.Fatalf("Failed to create file %s: %v", file, err)
-		}
-	}
-
-	// Run a simple test that doesn't depend on stdout capture
-	// We'll just verify that stats are collected correctly
-	config := Config{
-		Path:           tempDir,
-		ExcludeFolders: []string{"node_modules"},
-		ShowFiles:      true,
-		ExcludeFiles:   []string{".exe"},
-		ExcludeNames:   []string{"README.md"},
-		MaxDepth:       -1,
-		Unicode:        true,
-		Color:          false,
-		StatTable:      true,
-	}
-
-	stats := Stats{
-		FileTypes:  make(map[string]int64),
-		LargeFiles: []FileInfo{},
-	}
-
-	treeChars := getTreeChars(config.Unicode, config.Compact)
-
-	// Call walkDir (but we won't check the output, just the stats)
-	walkDir(config, config.Path, "", "", 0, treeChars, &stats)
-
-	// Verify statistics
-	expectedDirs := 4  // tempDir, dir1, dir1/subdir1, dir1/subdir2, dir2 (excluding node_modules)
-	if stats.TotalDirs != expectedDirs {
-		t.Errorf("Expected %d directories, got %d", expectedDirs, stats.TotalDirs)
-	}
-
-	expectedFiles := 3  // file1.txt, dir1/file3.txt, dir1/subdir1/file4.txt (excluding .exe and README.md)
-	if stats.TotalFiles != expectedFiles {
-		t.Errorf("Expected %d files, got %d", expectedFiles, stats.TotalFiles)
-	}
-
-	// Check that the correct file types were counted
-	if _, exists := stats.FileTypes[".txt"]; !exists {
-		t.Error("Expected .txt in file types, but it wasn't found")
-	}
-
-	if _, exists := stats.FileTypes[".exe"]; exists {
-		t.Error("Found .exe in file types, but it should have been excluded")
-	}
-}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Characters that have a meaning in Markdown text
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+// Write the tree and statistics as Markdown: the tree in a fenced code
+// block or as a nested list with links, and the statistics as tables
+func writeMarkdown(w io.Writer, root *Node, stats Stats, config Config) error {
+	var buf bytes.Buffer
+
+	if config.MarkdownList {
+		writeMarkdownList(&buf, root, config)
+	} else {
+		var tree bytes.Buffer
+		treeChars := getTreeChars(config.Unicode, config.Compact)
+		renderer := NewBasicRenderer(&tree, config, treeChars)
+		renderer.RenderRoot(root)
+		if root.IsDir() {
+			walkDir(renderer, root, "")
+		}
+		writeFenced(&buf, "text", tree.String())
+	}
+
+	if config.ShowStats || config.StatTable || config.Chart {
+		writeMarkdownStats(&buf, stats, config)
+	}
+	if config.GitAge {
+		writeMarkdownHistory(&buf, stats, config)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Write text in a fenced code block, with a fence longer than any run of
+// backticks in the text
+func writeFenced(w io.Writer, lang, text string) {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(w, "%s%s\n%s", fence, lang, text)
+	if !strings.HasSuffix(text, "\n") {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, fence)
+}
+
+// Write the tree as a nested bullet list. Directories are shown in bold and
+// files link to their path relative to the working directory, or to the
+// scanned root when it is given as an absolute path.
+func writeMarkdownList(w io.Writer, root *Node, config Config) {
+	fmt.Fprintf(w, "**%s/**\n\n", escapeMarkdown(root.Name))
+
+	var writeChildren func(node *Node, indent string)
+	writeChildren = func(node *Node, indent string) {
+		for _, child := range node.Children {
+			mark := ""
+			if code := child.Git.code(); code != "" {
+				mark = "`" + code + "` "
+			}
+			if child.IsDir() {
+				fmt.Fprintf(w, "%s- %s**%s/**%s%s%s\n", indent, mark, escapeMarkdown(child.Name), escapeMarkdown(linkNote(child)), collapsedNote(child), escapeMarkdown(errorNote(child)))
+				writeChildren(child, indent+"  ")
+				continue
+			}
+			fmt.Fprintf(w, "%s- %s[%s](%s)%s\n", indent, mark, escapeMarkdown(child.Name), markdownLink(child.Path, config.Path), escapeMarkdown(linkNote(child)+errorNote(child)))
+		}
+	}
+	writeChildren(root, "")
+}
+
+// Get the link target of a file relative to the scanned root, with each
+// path segment URL-escaped
+func markdownLink(path, root string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		path = rel
+	}
+
+	segments := strings.Split(filepath.ToSlash(path), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Escape the characters of a name that Markdown would interpret
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// Write the statistics that printStats shows as Markdown tables
+func writeMarkdownStats(w io.Writer, stats Stats, config Config) {
+	fmt.Fprintln(w, "\n### Statistics")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Directories | Files | Total Size |")
+	fmt.Fprintln(w, "|------------:|------:|-----------:|")
+	fmt.Fprintf(w, "| %d | %d | %s |\n", stats.TotalDirs, stats.TotalFiles, formatSize(stats.TotalSize))
+
+	// Largest files table if requested
+	if config.StatTable && len(stats.LargeFiles) > 0 {
+		largeFiles := append([]FileInfo(nil), stats.LargeFiles...)
+		sort.SliceStable(largeFiles, func(i, j int) bool {
+			return largeFiles[i].Size > largeFiles[j].Size
+		})
+		if config.StatsCount >= 0 && len(largeFiles) > config.StatsCount {
+			largeFiles = largeFiles[:config.StatsCount]
+		}
+
+		fmt.Fprintln(w, "\n### Largest Files")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| Size | Path | Type |")
+		fmt.Fprintln(w, "|-----:|------|------|")
+		for _, file := range largeFiles {
+			relativePath, err := filepath.Rel(config.Path, file.Path)
+			if err != nil {
+				relativePath = file.Path
+			}
+			fmt.Fprintf(w, "| %s | %s | %s |\n", formatSize(file.Size), escapeMarkdown(filepath.ToSlash(relativePath)), escapeMarkdown(file.Type))
+		}
+	}
+
+	// File type distribution
+	if len(stats.FileTypes) > 0 {
+		fmt.Fprintln(w, "\n### File Type Distribution")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| Type | Size | Percentage |")
+		fmt.Fprintln(w, "|------|-----:|-----------:|")
+		for _, info := range fileTypeDistribution(stats, -1) {
+			fmt.Fprintf(w, "| %s | %s | %.1f%% |\n", escapeMarkdown(info.Ext), formatSize(info.Size), percentOf(info.Size, stats.TotalSize))
+		}
+	}
+
+	// The chart keeps its text layout
+	if config.Chart && len(stats.FileTypes) > 0 {
+		chart := getChartRenderer(config).renderChart(stats, config)
+		fmt.Fprintln(w)
+		writeFenced(w, "text", strings.TrimLeft(chart, "\n"))
+	}
+}
+
+// Write the most changed files and the oldest paths as Markdown tables
+func writeMarkdownHistory(w io.Writer, stats Stats, config Config) {
+	tables := []struct {
+		title string
+		rows  []PathHistory
+	}{
+		{"Most Changed Files", stats.MostChanged},
+		{"Oldest Untouched Paths", stats.Oldest},
+	}
+
+	for _, table := range tables {
+		if len(table.rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n### %s\n\n", table.title)
+		fmt.Fprintln(w, "| Commits | Last Commit | Author | Path |")
+		fmt.Fprintln(w, "|--------:|-------------|--------|------|")
+		for _, row := range limitHistory(table.rows, config.StatsCount) {
+			relativePath, err := filepath.Rel(config.Path, row.Path)
+			if err != nil {
+				relativePath = row.Path
+			}
+			fmt.Fprintf(w, "| %d | %s | %s | %s |\n", row.Commits, row.LastCommit.Format(commitDateLayout),
+				escapeMarkdown(row.Author), escapeMarkdown(filepath.ToSlash(relativePath)))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"main.go", "main.go"},
+		{"user_guide.md", `user\_guide.md`},
+		{"[draft]*.txt", `\[draft\]\*.txt`},
+		{"a|b", `a\|b`},
+	}
+
+	for _, test := range tests {
+		result := escapeMarkdown(test.input)
+		if result != test.expected {
+			t.Errorf("escapeMarkdown(%q): expected %q, got %q", test.input, test.expected, result)
+		}
+	}
+}
+
+func TestMarkdownLink(t *testing.T) {
+	tests := []struct {
+		path, root string
+		expected   string
+	}{
+		{"docs/guide.md", "docs", "guide.md"},
+		{"docs/my notes.md", "docs", "my%20notes.md"},
+		{"docs/design/storage.md", "./docs", "design/storage.md"},
+		{"/srv/site/a/b.html", "/srv/site", "a/b.html"},
+	}
+
+	for _, test := range tests {
+		result := markdownLink(test.path, test.root)
+		if result != test.expected {
+			t.Errorf("markdownLink(%q, %q): expected %q, got %q", test.path, test.root, test.expected, result)
+		}
+	}
+}
+
+func TestWriteFenced(t *testing.T) {
+	var buf bytes.Buffer
+	writeFenced(&buf, "text", "a ``` b\n")
+
+	expected := "````text\na ``` b\n````\n"
+	if buf.String() != expected {
+		t.Errorf("writeFenced: expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	config.ShowStats = true
+	config.StatTable = false
+	stats := newTestStats()
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, root, stats, config); err != nil {
+		t.Fatalf("writeMarkdown failed: %v", err)
+	}
+	output := buf.String()
+
+	expected := []string{
+		"```text\n",
+		"└── file1.txt\n```\n",
+		"| Directories | Files | Total Size |",
+		"| 4 | 3 |",
+		"| .txt |",
+	}
+	for _, text := range expected {
+		if !strings.Contains(output, text) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", text, output)
+		}
+	}
+	if strings.Contains(output, "### Largest Files") {
+		t.Errorf("Expected no largest files table without --stat-table")
+	}
+
+	buf.Reset()
+	config.MarkdownList = true
+	config.ShowStats = false
+	if err := writeMarkdown(&buf, root, stats, config); err != nil {
+		t.Fatalf("writeMarkdown failed: %v", err)
+	}
+	expectedList := "- **dir1/**\n  - **subdir1/**\n    - [file4.txt](" + markdownLink(tempDir+"/dir1/subdir1/file4.txt", tempDir) + ")\n"
+	if !strings.Contains(buf.String(), expectedList) {
+		t.Errorf("Expected the list to contain %q, got:\n%s", expectedList, buf.String())
+	}
+}
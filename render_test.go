@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"io/fs"
 	"os"
 	"testing"
 )
@@ -102,3 +104,63 @@ func TestGetFileExtension(t *testing.T) {
 		}
 	}
 }
+
+func TestBasicRendererWalkDir(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	stats := newTestStats()
+
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+	root.Name = "root"
+
+	var buf bytes.Buffer
+	renderer := NewBasicRenderer(&buf, config, getTreeChars(false, false))
+	renderer.RenderRoot(root)
+	walkDir(renderer, root, "")
+
+	expected := "root\n" +
+		"+-- dir1\n" +
+		"|   +-- subdir1\n" +
+		"|   |   `-- file4.txt\n" +
+		"|   +-- subdir2\n" +
+		"|   `-- file3.txt\n" +
+		"+-- dir2\n" +
+		"`-- file1.txt\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected tree output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestGetRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	treeChars := getTreeChars(true, false)
+
+	if _, ok := GetRenderer(&buf, Config{Color: true}, treeChars).(*ColorRenderer); !ok {
+		t.Errorf("GetRenderer with Color=true should return ColorRenderer")
+	}
+
+	if _, ok := GetRenderer(&buf, Config{Color: false}, treeChars).(*BasicRenderer); !ok {
+		t.Errorf("GetRenderer with Color=false should return BasicRenderer")
+	}
+}
+
+func TestLinkNote(t *testing.T) {
+	tests := []struct {
+		node     *Node
+		expected string
+	}{
+		{&Node{Name: "file.txt"}, ""},
+		{&Node{Name: "link", Mode: fs.ModeSymlink, LinkTarget: "target"}, " -> target"},
+		{&Node{Name: "gone", Mode: fs.ModeSymlink, LinkTarget: "missing", LinkBroken: true}, " -> missing"},
+		{&Node{Name: "up", Mode: fs.ModeDir | fs.ModeSymlink, LinkTarget: "..", LinkLoop: true}, " -> .. [recursive, not followed]"},
+	}
+
+	for _, test := range tests {
+		if result := linkNote(test.node); result != test.expected {
+			t.Errorf("linkNote(%q): expected %q, got %q", test.node.Name, test.expected, result)
+		}
+	}
+}
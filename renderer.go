@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -12,128 +13,183 @@ import (
 
 // Renderer represents a tree node renderer
 type Renderer interface {
-	RenderDir(name string, isLast bool, prefix string) (string, string)
-	RenderFile(name string, isLast bool, prefix string, isSymlink bool)
+	RenderRoot(node *Node)
+	RenderDir(node *Node, isLast bool, prefix string) (string, string)
+	RenderFile(node *Node, isLast bool, prefix string)
+	RenderError(node *Node, isLast bool, prefix string)
 }
 
 // ColorRenderer renders the tree with colors
 type ColorRenderer struct {
+	w io.Writer
 	config Config
 	treeChars TreeChars
+	columns *columnFormatter
+	gitWidth int
 }
 
 // Create a new color renderer
-func NewColorRenderer(config Config, treeChars TreeChars) *ColorRenderer {
+func NewColorRenderer(w io.Writer, config Config, treeChars TreeChars) *ColorRenderer {
 	return &ColorRenderer{
+		w: w,
 		config: config,
 		treeChars: treeChars,
 	}
 }
 
-// RenderDir renders a directory with color
-func (r *ColorRenderer) RenderDir(name string, isLast bool, prefix string) (string, string) {
-	var newPrefix, newLastPrefix string
-	
-	if isLast {
-		fmt.Print(prefix)
-		fmt.Print(r.treeChars.LastItem)
-		newPrefix = prefix + r.treeChars.Indent
-		newLastPrefix = prefix + r.treeChars.Indent
-	} else {
-		fmt.Print(prefix)
-		fmt.Print(r.treeChars.MiddleItem)
-		newPrefix = prefix + r.treeChars.Line
-		newLastPrefix = prefix + r.treeChars.Indent
-	}
-
-	if r.config.BgColor {
-		color.New(color.FgHiWhite, color.BgBlue).Printf("%s\n", name)
-	} else {
-		color.New(color.FgBlue, color.Bold).Printf("%s\n", name)
+// RenderRoot renders the root directory with color
+func (r *ColorRenderer) RenderRoot(node *Node) {
+	r.columns = newColumnFormatter(node, r.config)
+	if r.config.Git {
+		r.gitWidth = gitMarkWidth(node)
 	}
-	
+	if r.config.DiskUsage {
+		fmt.Fprint(r.w, r.columns.format(node))
+	}
+	r.config.Theme.color(node, r.config.BgColor).Fprint(r.w, node.Name)
+	r.endLine(node)
+}
+
+// RenderDir renders a directory with color
+func (r *ColorRenderer) RenderDir(node *Node, isLast bool, prefix string) (string, string) {
+	newPrefix, newLastPrefix := writeBranch(r.w, r.treeChars, isLast, prefix)
+	fmt.Fprint(r.w, r.columns.format(node))
+	writeGitMark(r.w, node.Git, r.gitWidth, true, r.config.BgColor)
+	r.config.Theme.color(node, r.config.BgColor).Fprint(r.w, node.Name)
+	fmt.Fprintf(r.w, "%s%s", linkNote(node), collapsedNote(node))
+	r.endLine(node)
 	return newPrefix, newLastPrefix
 }
 
 // RenderFile renders a file with color
-func (r *ColorRenderer) RenderFile(name string, isLast bool, prefix string, isSymlink bool) {
-	if isLast {
-		fmt.Print(prefix)
-		fmt.Print(r.treeChars.LastItem)
-	} else {
-		fmt.Print(prefix)
-		fmt.Print(r.treeChars.MiddleItem)
-	}
-
-	if isSymlink {
-		if r.config.BgColor {
-			color.New(color.FgHiWhite, color.BgMagenta).Printf("%s\n", name)
-		} else {
-			color.New(color.FgMagenta).Printf("%s\n", name)
-		}
-	} else {
-		if r.config.BgColor {
-			color.New(color.FgHiWhite, color.BgGreen).Printf("%s\n", name)
-		} else {
-			color.New(color.FgGreen).Printf("%s\n", name)
-		}
+func (r *ColorRenderer) RenderFile(node *Node, isLast bool, prefix string) {
+	writeBranch(r.w, r.treeChars, isLast, prefix)
+	fmt.Fprint(r.w, r.columns.format(node))
+	writeGitMark(r.w, node.Git, r.gitWidth, true, r.config.BgColor)
+
+	r.config.Theme.color(node, r.config.BgColor).Fprint(r.w, node.Name)
+	fmt.Fprintf(r.w, "%s\n", linkNote(node))
+}
+
+// RenderError renders a file that could not be read, marked in red
+func (r *ColorRenderer) RenderError(node *Node, isLast bool, prefix string) {
+	writeBranch(r.w, r.treeChars, isLast, prefix)
+	fmt.Fprint(r.w, r.columns.format(node))
+	writeGitMark(r.w, node.Git, r.gitWidth, true, r.config.BgColor)
+	newColor(color.FgRed).Fprintf(r.w, "%s%s\n", node.Name, errorNote(node))
+}
+
+// End the line of an entry with its error marker in red, if any
+func (r *ColorRenderer) endLine(node *Node) {
+	if note := errorNote(node); note != "" {
+		newColor(color.FgRed).Fprint(r.w, note)
 	}
+	fmt.Fprintln(r.w)
 }
 
 // BasicRenderer renders the tree without colors
 type BasicRenderer struct {
+	w io.Writer
+	config Config
 	treeChars TreeChars
+	columns *columnFormatter
+	gitWidth int
 }
 
 // Create a new basic renderer
-func NewBasicRenderer(treeChars TreeChars) *BasicRenderer {
+func NewBasicRenderer(w io.Writer, config Config, treeChars TreeChars) *BasicRenderer {
 	return &BasicRenderer{
+		w: w,
+		config: config,
 		treeChars: treeChars,
 	}
 }
 
+// RenderRoot renders the root directory without color
+func (r *BasicRenderer) RenderRoot(node *Node) {
+	r.columns = newColumnFormatter(node, r.config)
+	if r.config.Git {
+		r.gitWidth = gitMarkWidth(node)
+	}
+	if r.config.DiskUsage {
+		fmt.Fprint(r.w, r.columns.format(node))
+	}
+	fmt.Fprintf(r.w, "%s%s\n", node.Name, errorNote(node))
+}
+
 // RenderDir renders a directory without color
-func (r *BasicRenderer) RenderDir(name string, isLast bool, prefix string) (string, string) {
-	var newPrefix, newLastPrefix string
-	
-	if isLast {
-		fmt.Print(prefix)
-		fmt.Print(r.treeChars.LastItem)
-		newPrefix = prefix + r.treeChars.Indent
-		newLastPrefix = prefix + r.treeChars.Indent
-	} else {
-		fmt.Print(prefix)
-		fmt.Print(r.treeChars.MiddleItem)
-		newPrefix = prefix + r.treeChars.Line
-		newLastPrefix = prefix + r.treeChars.Indent
-	}
-
-	fmt.Printf("%s\n", name)
-	
+func (r *BasicRenderer) RenderDir(node *Node, isLast bool, prefix string) (string, string) {
+	newPrefix, newLastPrefix := writeBranch(r.w, r.treeChars, isLast, prefix)
+	fmt.Fprint(r.w, r.columns.format(node))
+	writeGitMark(r.w, node.Git, r.gitWidth, false, false)
+	fmt.Fprintf(r.w, "%s%s%s%s\n", node.Name, linkNote(node), collapsedNote(node), errorNote(node))
 	return newPrefix, newLastPrefix
 }
 
 // RenderFile renders a file without color
-func (r *BasicRenderer) RenderFile(name string, isLast bool, prefix string, isSymlink bool) {
+func (r *BasicRenderer) RenderFile(node *Node, isLast bool, prefix string) {
+	writeBranch(r.w, r.treeChars, isLast, prefix)
+	fmt.Fprint(r.w, r.columns.format(node))
+	writeGitMark(r.w, node.Git, r.gitWidth, false, false)
+	fmt.Fprintf(r.w, "%s%s\n", node.Name, linkNote(node))
+}
+
+// RenderError renders a file that could not be read, marked with its error
+func (r *BasicRenderer) RenderError(node *Node, isLast bool, prefix string) {
+	writeBranch(r.w, r.treeChars, isLast, prefix)
+	fmt.Fprint(r.w, r.columns.format(node))
+	writeGitMark(r.w, node.Git, r.gitWidth, false, false)
+	fmt.Fprintf(r.w, "%s%s\n", node.Name, errorNote(node))
+}
+
+// Helper functions for tree rendering
+
+// Write the prefix and branch characters for an entry and return the
+// prefixes for its children
+func writeBranch(w io.Writer, treeChars TreeChars, isLast bool, prefix string) (string, string) {
 	if isLast {
-		fmt.Print(prefix)
-		fmt.Print(r.treeChars.LastItem)
-	} else {
-		fmt.Print(prefix)
-		fmt.Print(r.treeChars.MiddleItem)
+		fmt.Fprint(w, prefix, treeChars.LastItem)
+		return prefix + treeChars.Indent, prefix + treeChars.Indent
 	}
+	fmt.Fprint(w, prefix, treeChars.MiddleItem)
+	return prefix + treeChars.Line, prefix + treeChars.Indent
+}
 
-	fmt.Printf("%s\n", name)
+// Get the target shown after a symlink name, noting links that were not
+// followed because they loop back to a directory above
+func linkNote(node *Node) string {
+	if !node.IsSymlink() {
+		return ""
+	}
+	note := " -> " + node.LinkTarget
+	if node.LinkLoop {
+		note += " [recursive, not followed]"
+	}
+	return note
 }
 
-// Helper functions for tree rendering
+// Get the total size shown after a collapsed directory
+func collapsedNote(node *Node) string {
+	if !node.Collapsed {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", formatSize(node.Size))
+}
+
+// Get the marker shown after an entry that could not be read
+func errorNote(node *Node) string {
+	if node.Err == nil {
+		return ""
+	}
+	return " [error: " + errorText(node.Err) + "]"
+}
 
 // GetRenderer returns the appropriate renderer based on config
-func GetRenderer(config Config, treeChars TreeChars) Renderer {
+func GetRenderer(w io.Writer, config Config, treeChars TreeChars) Renderer {
 	if config.Color {
-		return NewColorRenderer(config, treeChars)
+		return NewColorRenderer(w, config, treeChars)
 	}
-	return NewBasicRenderer(treeChars)
+	return NewBasicRenderer(w, config, treeChars)
 }
 
 // ShouldExcludeFolder checks if a folder should be excluded
@@ -168,6 +224,10 @@ func ShouldExcludeFile(name string, excludeFiles, excludeNames []string) bool {
 // GetFileExtension gets the file extension in lowercase (with dot)
 func GetFileExtension(path string) string {
 	ext := strings.ToLower(filepath.Ext(path))
+	// Dotfiles such as ".hidden" have no extension
+	if ext == strings.ToLower(filepath.Base(path)) {
+		return ""
+	}
 	return ext
 }
 
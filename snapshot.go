@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Version of the snapshot file format
+const snapshotVersion = 1
+
+// snapshot is a scanned tree saved to disk for later comparison
+type snapshot struct {
+	Version int       `json:"version"`
+	Path    string    `json:"path"`
+	Created time.Time `json:"created"`
+	Root    *jsonNode `json:"root"`
+	Stats   Stats     `json:"stats"`
+}
+
+// Run the snapshot subcommand
+func runSnapshot(args []string) int {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	flags := registerConfigFlags(fs)
+	out := fs.String("out", "", "File to write the snapshot to (default stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: hyperion snapshot [flags]")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+	if err := flags.checkNoArgs(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	config, err := flags.resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	snap, err := takeSnapshot(config)
+	if isGitError(err) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error accessing path %s: %v\n", config.Path, err)
+		return exitPathError
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating snapshot: %v\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+		w = f
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snap); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing snapshot: %v\n", err)
+		return exitFailure
+	}
+
+	writeErrorSummary(os.Stderr, snap.Stats.Errors)
+	if config.Strict && len(snap.Stats.Errors) > 0 {
+		return exitUnreadable
+	}
+	return 0
+}
+
+// Scan a path, including all files and their content hashes
+func takeSnapshot(config Config) (*snapshot, error) {
+	// Snapshots hold the JSON tree, with the types of all files
+	config.ShowFiles = true
+	config.Output = "json"
+
+	stats := Stats{
+		FileTypes:  make(map[string]int64),
+		LargeFiles: []FileInfo{},
+	}
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		return nil, err
+	}
+	hashTree(root)
+
+	return &snapshot{
+		Version: snapshotVersion,
+		Path:    config.Path,
+		Created: time.Now(),
+		Root:    toJSONNode(root),
+		Stats:   stats,
+	}, nil
+}
+
+// Load a snapshot written by the snapshot subcommand
+func loadSnapshot(path string) (*snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("%s is not a valid snapshot: %v", path, err)
+	}
+	if snap.Root == nil {
+		return nil, fmt.Errorf("%s is not a valid snapshot: missing root", path)
+	}
+	if snap.Version > snapshotVersion {
+		return nil, fmt.Errorf("%s uses snapshot version %d, newer than supported version %d", path, snap.Version, snapshotVersion)
+	}
+	return &snap, nil
+}
+
+// Compute the content hash of every regular file in the tree. Files that
+// cannot be read are left without a hash.
+func hashTree(node *Node) {
+	if node.Mode.IsRegular() && node.Err == nil {
+		node.Hash, _ = hashFile(node.Path)
+	}
+	for _, child := range node.Children {
+		hashTree(child)
+	}
+}
+
+// Compute the SHA-256 hash of a file's content
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
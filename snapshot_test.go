@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	hash, err := hashFile(path)
+	if err != nil {
+		t.Fatalf("hashFile failed: %v", err)
+	}
+
+	expected := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	if hash != expected {
+		t.Errorf("hashFile(%q): expected %s, got %s", path, expected, hash)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	config.ShowFiles = false // Snapshots always include files
+
+	snap, err := takeSnapshot(config)
+	if err != nil {
+		t.Fatalf("takeSnapshot failed: %v", err)
+	}
+
+	var file *jsonNode
+	for _, child := range snap.Root.Children {
+		if child.Name == "file1.txt" {
+			file = child
+		}
+	}
+	if file == nil {
+		t.Fatalf("Expected file1.txt in the snapshot")
+	}
+	if len(file.Hash) != 64 {
+		t.Errorf("Expected a SHA-256 hash for file1.txt, got %q", file.Hash)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if code := runSnapshot([]string{"--path", tempDir, "--exclude-files", ".exe", "--exclude-names", "README.md", "--out", path}); code != 0 {
+		t.Fatalf("runSnapshot returned %d", code)
+	}
+
+	loaded, err := loadSnapshot(path)
+	if err != nil {
+		t.Fatalf("loadSnapshot failed: %v", err)
+	}
+	if loaded.Version != snapshotVersion {
+		t.Errorf("Expected version %d, got %d", snapshotVersion, loaded.Version)
+	}
+	if root := diffTrees(snap.Root, loaded.Root); root.Status != diffUnchanged {
+		t.Errorf("Expected the loaded snapshot to match the scanned tree")
+	}
+}
+
+func TestLoadSnapshotInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(path, []byte(`{"version": 1}`), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if _, err := loadSnapshot(path); err == nil {
+		t.Errorf("Expected an error for a snapshot without a root")
+	}
+}
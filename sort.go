@@ -0,0 +1,144 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Valid values for the --sort flag
+var sortModes = []string{"name", "size", "mtime", "ext", "natural"}
+
+// Valid directory placements, set by --dirs-first, --dirs-last and --mixed
+const (
+	dirsFirst = "first"
+	dirsLast  = "last"
+	dirsMixed = "mixed"
+)
+
+// Check whether a sort mode is supported
+func isValidSortMode(mode string) bool {
+	for _, m := range sortModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort the children of every directory in the tree
+func sortTree(node *Node, config Config) {
+	sortNodes(node.Children, config)
+	for _, child := range node.Children {
+		if child.IsDir() {
+			sortTree(child, config)
+		}
+	}
+}
+
+// Sort sibling nodes by the configured mode and directory placement.
+// Sizes sort largest first and times newest first; --reverse flips the
+// order within each group but not the directory placement.
+func sortNodes(nodes []*Node, config Config) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+
+		if a.IsDir() != b.IsDir() {
+			switch config.DirOrder {
+			case dirsLast:
+				return b.IsDir()
+			case dirsMixed:
+			default:
+				return a.IsDir()
+			}
+		}
+
+		if config.Reverse {
+			a, b = b, a
+		}
+		return compareNodes(a, b, config.Sort) < 0
+	})
+}
+
+// Compare two nodes by sort mode, falling back to the name
+func compareNodes(a *Node, b *Node, mode string) int {
+	switch mode {
+	case "size":
+		if a.Size != b.Size {
+			if a.Size > b.Size {
+				return -1
+			}
+			return 1
+		}
+	case "mtime":
+		if !a.ModTime.Equal(b.ModTime) {
+			if a.ModTime.After(b.ModTime) {
+				return -1
+			}
+			return 1
+		}
+	case "ext":
+		if c := strings.Compare(getFileExtension(a.Name), getFileExtension(b.Name)); c != 0 {
+			return c
+		}
+	case "natural":
+		if c := compareNatural(a.Name, b.Name); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// Compare strings so that embedded numbers sort by value ("file2" < "file10")
+func compareNatural(a string, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			numA, restA := splitNumber(a)
+			numB, restB := splitNumber(b)
+
+			// Compare by value: ignore leading zeros, then compare length and digits
+			trimA := strings.TrimLeft(numA, "0")
+			trimB := strings.TrimLeft(numB, "0")
+			if len(trimA) != len(trimB) {
+				if len(trimA) < len(trimB) {
+					return -1
+				}
+				return 1
+			}
+			if c := strings.Compare(trimA, trimB); c != 0 {
+				return c
+			}
+			a, b = restA, restB
+			continue
+		}
+
+		ca, cb := lowerASCII(a[0]), lowerASCII(b[0])
+		if ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+// Split a leading run of digits from a string
+func splitNumber(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func lowerASCII(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestCompareNatural(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file02", "file2", 0},
+		{"a", "B", -1},
+		{"img12b", "img12a", 1},
+		{"v1.9", "v1.10", -1},
+		{"abc", "abcd", -1},
+	}
+
+	for _, test := range tests {
+		result := compareNatural(test.a, test.b)
+		if (result < 0) != (test.expected < 0) || (result > 0) != (test.expected > 0) {
+			t.Errorf("compareNatural(%q, %q): expected sign of %d, got %d", test.a, test.b, test.expected, result)
+		}
+	}
+}
+
+func TestSortNodes(t *testing.T) {
+	now := time.Now()
+	newNodes := func() []*Node {
+		return []*Node{
+			{Name: "file10.txt", Size: 5, ModTime: now.Add(-3 * time.Hour)},
+			{Name: "big", Mode: fs.ModeDir, Size: 500, ModTime: now.Add(-2 * time.Hour)},
+			{Name: "file2.go", Size: 50, ModTime: now},
+			{Name: "alpha", Mode: fs.ModeDir, Size: 1, ModTime: now.Add(-time.Hour)},
+		}
+	}
+
+	tests := []struct {
+		sort     string
+		reverse  bool
+		dirOrder string
+		expected []string
+	}{
+		{"name", false, dirsFirst, []string{"alpha", "big", "file10.txt", "file2.go"}},
+		{"name", true, dirsFirst, []string{"big", "alpha", "file2.go", "file10.txt"}},
+		{"name", false, dirsLast, []string{"file10.txt", "file2.go", "alpha", "big"}},
+		{"natural", false, dirsMixed, []string{"alpha", "big", "file2.go", "file10.txt"}},
+		{"size", false, dirsMixed, []string{"big", "file2.go", "file10.txt", "alpha"}},
+		{"size", true, dirsFirst, []string{"alpha", "big", "file10.txt", "file2.go"}},
+		{"mtime", false, dirsMixed, []string{"file2.go", "alpha", "big", "file10.txt"}},
+		{"ext", false, dirsLast, []string{"file2.go", "file10.txt", "alpha", "big"}},
+	}
+
+	for _, test := range tests {
+		nodes := newNodes()
+		sortNodes(nodes, Config{Sort: test.sort, Reverse: test.reverse, DirOrder: test.dirOrder})
+		for i, name := range test.expected {
+			if nodes[i].Name != name {
+				t.Errorf("sort=%s reverse=%v dirs=%s: position %d expected %q, got %q",
+					test.sort, test.reverse, test.dirOrder, i, name, nodes[i].Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !illumos && !linux && !netbsd && !openbsd && !solaris
+
+package main
+
+import "io/fs"
+
+// Inodes and Unix ownership are not available on Windows and other
+// platforms without a Unix stat structure
+func getSysInfo(info fs.FileInfo) *sysInfo {
+	return nil
+}
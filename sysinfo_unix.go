@@ -0,0 +1,22 @@
+//go:build aix || darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || solaris
+
+package main
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// Get the inode, device and ownership of a file
+func getSysInfo(info fs.FileInfo) *sysInfo {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return &sysInfo{
+		Inode: uint64(st.Ino),
+		Dev:   uint64(st.Dev),
+		UID:   st.Uid,
+		GID:   st.Gid,
+	}
+}
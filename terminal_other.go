@@ -0,0 +1,25 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// terminal is not implemented on this platform
+type terminal struct{}
+
+// Interactive mode needs a Unix terminal
+func openTerminal() (*terminal, error) {
+	return nil, fmt.Errorf("interactive mode is not supported on %s", runtime.GOOS)
+}
+
+func (t *terminal) size() (int, int) {
+	return 80, 24
+}
+
+func (t *terminal) restore() {}
+
+func notifyResize(ch chan<- os.Signal) {}
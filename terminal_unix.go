@@ -0,0 +1,66 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminal is the controlling terminal switched to raw mode
+type terminal struct {
+	fd    int
+	state unix.Termios
+}
+
+// Switch the terminal to raw mode and the alternate screen
+func openTerminal() (*terminal, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, fmt.Errorf("interactive mode needs a terminal")
+	}
+	if _, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ); err != nil {
+		return nil, fmt.Errorf("interactive mode needs a terminal")
+	}
+
+	raw := *state
+	raw.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	raw.Oflag &^= unix.OPOST
+	raw.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	raw.Cflag &^= unix.CSIZE | unix.PARENB
+	raw.Cflag |= unix.CS8
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+
+	// Alternate screen, hidden cursor
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	return &terminal{fd: fd, state: *state}, nil
+}
+
+// Get the terminal width and height
+func (t *terminal) size() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return 80, 24
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+// Restore the screen and the terminal mode
+func (t *terminal) restore() {
+	fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+	unix.IoctlSetTermios(t.fd, ioctlSetTermios, &t.state)
+}
+
+// Send a signal on the channel when the terminal is resized
+func notifyResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+)
+
+// Theme holds the colors of entries as SGR codes such as "01;34", the
+// format of LS_COLORS. Kinds use the dircolors keys: di for directories,
+// ex for executables, su for setuid files, st for sticky directories...
+type Theme struct {
+	Kinds      map[string]string
+	Suffixes   map[string]string
+	Categories map[string]string
+}
+
+// Built-in themes selected with --theme
+var builtinThemes = map[string]func() *Theme{
+	"dark":  darkTheme,
+	"light": lightTheme,
+	"ls":    lsTheme,
+}
+
+// Keys of a theme file and the dircolors kinds they set
+var themeKinds = map[string][]string{
+	"dir":             {"di"},
+	"file":            {"fi"},
+	"symlink":         {"ln"},
+	"broken":          {"or"},
+	"executable":      {"ex"},
+	"setuid":          {"su"},
+	"setgid":          {"sg"},
+	"sticky":          {"st"},
+	"sticky-writable": {"tw"},
+	"other-writable":  {"ow"},
+	"pipe":            {"pi"},
+	"socket":          {"so"},
+	"device":          {"bd", "cd"},
+}
+
+// The colors hyperion has always used, with file names colored by category
+func darkTheme() *Theme {
+	return &Theme{
+		Kinds: map[string]string{
+			"di": "34;1", "fi": "32", "ln": "35", "or": "31", "ex": "92;1",
+			"su": "37;41", "sg": "30;43", "st": "37;44", "tw": "30;42", "ow": "34;42",
+			"pi": "33", "so": "95", "bd": "93", "cd": "93",
+		},
+		Suffixes: map[string]string{},
+		Categories: map[string]string{
+			categorySource:   "32",
+			categoryDocument: "37",
+			categoryImage:    "95",
+			categoryMedia:    "36",
+			categoryArchive:  "91",
+			categoryBinary:   "92",
+			categoryData:     "33",
+		},
+	}
+}
+
+// Darker colors that stay readable on a light background
+func lightTheme() *Theme {
+	return &Theme{
+		Kinds: map[string]string{
+			"di": "34;1", "fi": "30", "ln": "35", "or": "31;1", "ex": "32;1",
+			"su": "37;41", "sg": "30;43", "st": "37;44", "tw": "30;42", "ow": "34;42",
+			"pi": "33", "so": "35;1", "bd": "33;1", "cd": "33;1",
+		},
+		Suffixes: map[string]string{},
+		Categories: map[string]string{
+			categorySource:   "32",
+			categoryDocument: "30",
+			categoryImage:    "35",
+			categoryMedia:    "36",
+			categoryArchive:  "31",
+			categoryBinary:   "32;1",
+			categoryData:     "38;5;130",
+		},
+	}
+}
+
+// The defaults of GNU ls, used under LS_COLORS. Files are only colored by
+// suffix, as ls does.
+func lsTheme() *Theme {
+	return &Theme{
+		Kinds: map[string]string{
+			"di": "01;34", "ln": "01;36", "or": "40;31;01", "ex": "01;32",
+			"su": "37;41", "sg": "30;43", "st": "37;44", "tw": "30;42", "ow": "34;42",
+			"pi": "40;33", "so": "01;35", "bd": "40;33;01", "cd": "40;33;01",
+		},
+		Suffixes:   map[string]string{},
+		Categories: map[string]string{},
+	}
+}
+
+// Load the theme named by --theme: a built-in theme or a theme file. Without
+// a name the theme follows LS_COLORS when it is set, and is dark otherwise.
+func loadTheme(name string) (*Theme, error) {
+	if name == "" {
+		if lsColors := os.Getenv("LS_COLORS"); lsColors != "" {
+			theme := lsTheme()
+			theme.applyLSColors(lsColors)
+			return theme, nil
+		}
+		return darkTheme(), nil
+	}
+	if builtin, ok := builtinThemes[name]; ok {
+		return builtin(), nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read theme %s: %v", name, err)
+	}
+	return parseThemeFile(name, data)
+}
+
+// Apply the entries of an LS_COLORS value, such as "di=01;34:*.go=32".
+// Malformed entries are skipped.
+func (t *Theme) applyLSColors(value string) {
+	for _, entry := range strings.Split(value, ":") {
+		key, codes, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if strings.HasPrefix(key, "*") {
+			t.Suffixes[strings.ToLower(key[1:])] = codes
+		} else {
+			t.Kinds[key] = codes
+		}
+	}
+}
+
+// Parse a YAML or TOML theme file. It starts from a built-in theme, dark
+// unless "base" names another, and overrides its colors.
+func parseThemeFile(path string, data []byte) (*Theme, error) {
+	raw := make(map[string]interface{})
+	var err error
+	if filepath.Ext(path) == ".toml" {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse theme %s: %v", path, err)
+	}
+
+	theme := darkTheme()
+	if base, ok := raw["base"]; ok {
+		builtin, ok := builtinThemes[fmt.Sprint(base)]
+		if !ok {
+			return nil, fmt.Errorf("invalid theme %s: unknown base theme %v", path, base)
+		}
+		theme = builtin()
+		delete(raw, "base")
+	}
+
+	for key, value := range raw {
+		switch key {
+		case "extensions", "categories":
+			table, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid theme %s: expected a table of colors for %s", path, key)
+			}
+			for name, spec := range table {
+				codes, err := parseColorSpec(fmt.Sprint(spec))
+				if err != nil {
+					return nil, fmt.Errorf("invalid theme %s: %s %s: %v", path, key, name, err)
+				}
+				if key == "extensions" {
+					theme.Suffixes[strings.ToLower(name)] = codes
+				} else {
+					theme.Categories[name] = codes
+				}
+			}
+		default:
+			kinds, ok := themeKinds[key]
+			if !ok {
+				return nil, fmt.Errorf("invalid theme %s: unknown key %q", path, key)
+			}
+			codes, err := parseColorSpec(fmt.Sprint(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid theme %s: %s: %v", path, key, err)
+			}
+			for _, kind := range kinds {
+				theme.Kinds[kind] = codes
+			}
+		}
+	}
+	return theme, nil
+}
+
+// Names of the colors accepted in theme files, with their SGR codes
+var colorNames = map[string]int{
+	"black": 30, "red": 31, "green": 32, "yellow": 33,
+	"blue": 34, "magenta": 35, "cyan": 36, "white": 37,
+}
+
+// Names of the text styles accepted in theme files
+var styleNames = map[string]int{
+	"bold": 1, "dim": 2, "italic": 3, "underline": 4, "blink": 5, "reverse": 7,
+}
+
+// Convert a color of a theme file to SGR codes. It is either SGR codes as
+// in LS_COLORS, such as "01;34", or words such as "bold bright-red on-blue".
+func parseColorSpec(spec string) (string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return "", fmt.Errorf("empty color")
+	}
+	if _, err := parseCodes(spec); err == nil {
+		return spec, nil
+	}
+
+	var codes []string
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		if code, ok := styleNames[word]; ok {
+			codes = append(codes, strconv.Itoa(code))
+			continue
+		}
+
+		name, offset := word, 0
+		if strings.HasPrefix(name, "on-") {
+			name, offset = name[3:], 10
+		}
+		if strings.HasPrefix(name, "bright-") {
+			name, offset = name[7:], offset+60
+		}
+		code, ok := colorNames[name]
+		if !ok {
+			return "", fmt.Errorf("unknown color %q", word)
+		}
+		codes = append(codes, strconv.Itoa(code+offset))
+	}
+	return strings.Join(codes, ";"), nil
+}
+
+// Split SGR codes such as "01;34" into numbers
+func parseCodes(codes string) ([]int, error) {
+	var numbers []int
+	for _, part := range strings.Split(codes, ";") {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// Get the SGR codes of an entry. The file mode comes first, then the name
+// suffix and the category, as in ls.
+func (t *Theme) codes(node *Node) string {
+	kind := t.kind(node)
+	if kind == "ln" && t.Kinds["ln"] == "target" {
+		kind = "fi"
+	}
+	if kind != "fi" {
+		return t.Kinds[kind]
+	}
+
+	// The longest matching suffix wins, so ".tar.gz" beats ".gz"
+	lower := strings.ToLower(node.Name)
+	best := ""
+	for suffix := range t.Suffixes {
+		if strings.HasSuffix(lower, suffix) && len(suffix) > len(best) {
+			best = suffix
+		}
+	}
+	if best != "" {
+		return t.Suffixes[best]
+	}
+	if codes, ok := t.Categories[node.FileType.Category]; ok {
+		return codes
+	}
+	return t.Kinds["fi"]
+}
+
+// Get the dircolors kind of an entry from its mode
+func (t *Theme) kind(node *Node) string {
+	mode := node.Mode
+	switch {
+	case node.LinkBroken:
+		return "or"
+	case mode&fs.ModeSymlink != 0 && !mode.IsDir():
+		return "ln"
+	case mode.IsDir():
+		writable := mode&0002 != 0
+		switch {
+		case mode&fs.ModeSticky != 0 && writable && t.has("tw"):
+			return "tw"
+		case writable && t.has("ow"):
+			return "ow"
+		case mode&fs.ModeSticky != 0 && t.has("st"):
+			return "st"
+		}
+		return "di"
+	case mode&fs.ModeNamedPipe != 0:
+		return "pi"
+	case mode&fs.ModeSocket != 0:
+		return "so"
+	case mode&fs.ModeCharDevice != 0:
+		return "cd"
+	case mode&fs.ModeDevice != 0:
+		return "bd"
+	case mode&fs.ModeSetuid != 0 && t.has("su"):
+		return "su"
+	case mode&fs.ModeSetgid != 0 && t.has("sg"):
+		return "sg"
+	case mode&0111 != 0 && t.has("ex"):
+		return "ex"
+	}
+	return "fi"
+}
+
+// Check whether the theme sets a color for a kind
+func (t *Theme) has(kind string) bool {
+	return t.Kinds[kind] != ""
+}
+
+// Get the color of an entry name. With bgColor, the foreground color
+// becomes the background and the text bright white, as --bg-color does.
+func (t *Theme) color(node *Node, bgColor bool) *color.Color {
+	if t == nil {
+		t = darkTheme()
+	}
+	codes, _ := parseCodes(t.codes(node))
+	if bgColor {
+		codes = backgroundCodes(codes)
+	}
+
+	attrs := make([]color.Attribute, len(codes))
+	for i, code := range codes {
+		attrs[i] = color.Attribute(code)
+	}
+	return newColor(attrs...)
+}
+
+// Turn foreground SGR codes into a background with bright white text.
+// Codes that already set a background or no color are kept as they are.
+func backgroundCodes(codes []int) []int {
+	for _, code := range codes {
+		if (code >= 40 && code <= 48) || (code >= 100 && code <= 107) {
+			return codes
+		}
+	}
+
+	result := []int{int(color.FgHiWhite)}
+	for i := 0; i < len(codes); i++ {
+		code := codes[i]
+		switch {
+		case code >= 30 && code <= 37, code >= 90 && code <= 97:
+			result = append(result, code+10)
+		case code == 38 && i+2 < len(codes) && codes[i+1] == 5:
+			result = append(result, 48, 5, codes[i+2])
+			i += 2
+		}
+	}
+	if len(result) == 1 {
+		return codes
+	}
+	return result
+}
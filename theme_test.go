@@ -0,0 +1,174 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseColorSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+		valid    bool
+	}{
+		{"01;34", "01;34", true},
+		{"38;5;208", "38;5;208", true},
+		{"red", "31", true},
+		{"bold blue", "1;34", true},
+		{"Bright-Red on-blue", "91;44", true},
+		{"on-bright-black", "100", true},
+		{"underline green", "4;32", true},
+		{"purple", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		result, err := parseColorSpec(test.spec)
+		if result != test.expected || (err == nil) != test.valid {
+			t.Errorf("parseColorSpec(%q): expected %q (valid %v), got %q (%v)", test.spec, test.expected, test.valid, result, err)
+		}
+	}
+}
+
+func TestBackgroundCodes(t *testing.T) {
+	tests := []struct {
+		codes    []int
+		expected []int
+	}{
+		{[]int{34, 1}, []int{97, 44}},
+		{[]int{91}, []int{97, 101}},
+		{[]int{38, 5, 130}, []int{97, 48, 5, 130}},
+		{[]int{37, 41}, []int{37, 41}},
+		{[]int{1}, []int{1}},
+		{nil, nil},
+	}
+
+	for _, test := range tests {
+		if result := backgroundCodes(test.codes); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("backgroundCodes(%v): expected %v, got %v", test.codes, test.expected, result)
+		}
+	}
+}
+
+func TestThemeCodes(t *testing.T) {
+	theme := lsTheme()
+	theme.applyLSColors("di=01;33:ex=32:*.tar.gz=01;31:*.gz=31:*Makefile=35:bogus:=1")
+
+	tests := []struct {
+		node     *Node
+		expected string
+	}{
+		{&Node{Name: "src", Mode: fs.ModeDir | 0755}, "01;33"},
+		{&Node{Name: "tmp", Mode: fs.ModeDir | fs.ModeSticky | 0777}, "30;42"},
+		{&Node{Name: "pub", Mode: fs.ModeDir | 0777}, "34;42"},
+		{&Node{Name: "repo", Mode: fs.ModeDir | fs.ModeSticky | 0755}, "37;44"},
+		{&Node{Name: "backup.TAR.GZ", Mode: 0644}, "01;31"},
+		{&Node{Name: "log.gz", Mode: 0644}, "31"},
+		{&Node{Name: "Makefile", Mode: 0644}, "35"},
+		{&Node{Name: "run.sh", Mode: 0755}, "32"},
+		{&Node{Name: "passwd", Mode: fs.ModeSetuid | 0755}, "37;41"},
+		{&Node{Name: "link", Mode: fs.ModeSymlink}, "01;36"},
+		{&Node{Name: "dangling", Mode: fs.ModeSymlink, LinkBroken: true}, "40;31;01"},
+		{&Node{Name: "photo.png", Mode: 0644, FileType: FileType{"PNG", categoryImage}}, ""},
+	}
+
+	for _, test := range tests {
+		if result := theme.codes(test.node); result != test.expected {
+			t.Errorf("codes(%s): expected %q, got %q", test.node.Name, test.expected, result)
+		}
+	}
+
+	// The dark theme colors files by category, and ln=target colors links
+	// as the files they point to
+	dark := darkTheme()
+	dark.Kinds["ln"] = "target"
+	image := &Node{Name: "photo", Mode: fs.ModeSymlink, FileType: FileType{"PNG", categoryImage}}
+	if result := dark.codes(image); result != "95" {
+		t.Errorf("codes(%s): expected %q, got %q", image.Name, "95", result)
+	}
+}
+
+func TestParseThemeFile(t *testing.T) {
+	yamlTheme := `
+base: light
+dir: bold magenta
+executable: "01;32"
+device: yellow
+extensions:
+  .LOG: dim white
+categories:
+  image: bright-cyan
+`
+	theme, err := parseThemeFile("theme.yaml", []byte(yamlTheme))
+	if err != nil {
+		t.Fatalf("parseThemeFile failed: %v", err)
+	}
+	expected := map[string]string{
+		"di": "1;35", "ex": "01;32", "bd": "33", "cd": "33",
+		"fi": lightTheme().Kinds["fi"],
+	}
+	for kind, codes := range expected {
+		if theme.Kinds[kind] != codes {
+			t.Errorf("parseThemeFile: expected %q for %s, got %q", codes, kind, theme.Kinds[kind])
+		}
+	}
+	if theme.Suffixes[".log"] != "2;37" || theme.Categories[categoryImage] != "96" {
+		t.Errorf("parseThemeFile: unexpected suffixes %v or categories %v", theme.Suffixes, theme.Categories)
+	}
+
+	tomlTheme := "symlink = \"cyan\"\n[extensions]\n\".go\" = \"blue\"\n"
+	theme, err = parseThemeFile("theme.toml", []byte(tomlTheme))
+	if err != nil {
+		t.Fatalf("parseThemeFile failed: %v", err)
+	}
+	if theme.Kinds["ln"] != "36" || theme.Suffixes[".go"] != "34" || theme.Kinds["di"] != darkTheme().Kinds["di"] {
+		t.Errorf("parseThemeFile: unexpected theme %+v", theme)
+	}
+
+	invalid := []string{
+		"base: solarized\n",
+		"directory: blue\n",
+		"dir: purple\n",
+		"extensions: blue\n",
+	}
+	for _, content := range invalid {
+		if _, err := parseThemeFile("theme.yaml", []byte(content)); err == nil {
+			t.Errorf("parseThemeFile(%q): expected an error", content)
+		}
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	t.Setenv("LS_COLORS", "di=01;36")
+	theme, err := loadTheme("")
+	if err != nil || theme.Kinds["di"] != "01;36" {
+		t.Errorf("loadTheme: expected LS_COLORS to set the directory color, got %v (%v)", theme, err)
+	}
+
+	theme, err = loadTheme("light")
+	if err != nil || !reflect.DeepEqual(theme, lightTheme()) {
+		t.Errorf("loadTheme(%q): expected the built-in theme, got %v (%v)", "light", theme, err)
+	}
+
+	t.Setenv("LS_COLORS", "")
+	theme, err = loadTheme("")
+	if err != nil || !reflect.DeepEqual(theme, darkTheme()) {
+		t.Errorf("loadTheme: expected the dark theme without LS_COLORS, got %v (%v)", theme, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "theme.yaml")
+	if err := os.WriteFile(path, []byte("file: white\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	theme, err = loadTheme(path)
+	if err != nil || theme.Kinds["fi"] != "37" {
+		t.Errorf("loadTheme(%q): expected the theme file, got %v (%v)", path, theme, err)
+	}
+
+	if _, err := loadTheme(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadTheme: expected an error for a missing theme file")
+	}
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Node is a single entry of the scanned directory tree. The size of a
+// directory is the total size of the files below it, and FileCount is the
+// number of those files.
+type Node struct {
+	Name       string
+	Path       string
+	Mode       fs.FileMode
+	Size       int64
+	FileCount  int
+	ModTime    time.Time
+	LinkTarget string
+	LinkBroken bool
+	LinkLoop   bool
+	Hash       string
+	Collapsed  bool
+	Git        gitStatus
+	History    *GitHistory
+	FileType   FileType
+	Sys        *sysInfo
+	Children   []*Node
+	Err        error
+}
+
+// Version control directories shown as single entries with --collapse-vcs
+var vcsDirs = map[string]bool{".git": true, ".svn": true, ".hg": true}
+
+// dirChain is the list of directories from the scan root down to the
+// directory being scanned, used to detect symlink cycles
+type dirChain struct {
+	id     string
+	parent *dirChain
+}
+
+// Check whether a directory is in the chain
+func (c *dirChain) contains(id string) bool {
+	for ; c != nil; c = c.parent {
+		if c.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// sysInfo holds platform-specific file metadata
+type sysInfo struct {
+	Inode uint64
+	Dev   uint64
+	UID   uint32
+	GID   uint32
+}
+
+// IsDir reports whether the node is a directory
+func (n *Node) IsDir() bool {
+	return n.Mode.IsDir()
+}
+
+// IsSymlink reports whether the node is a symbolic link
+func (n *Node) IsSymlink() bool {
+	return n.Mode&os.ModeSymlink != 0
+}
+
+// scanner builds the node tree, reading up to config.Jobs directories
+// concurrently and merging their statistics into stats
+type scanner struct {
+	config Config
+	stats  *Stats
+	mu     sync.Mutex
+	sem    chan struct{}
+	filter *pathFilter
+
+	// Whether file contents are read to classify unknown names
+	sniff bool
+
+	// Scan root relative to the gitignore root, slash-separated
+	ignoreBase string
+}
+
+// Create a new scanner
+func newScanner(config Config, stats *Stats) *scanner {
+	jobs := config.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	return &scanner{
+		config: config,
+		stats:  stats,
+		sniff:  usesFileTypes(config),
+		// The calling goroutine is one of the workers
+		sem: make(chan struct{}, jobs-1),
+	}
+}
+
+// Scan the root path into a tree of nodes
+func scanTree(config Config, stats *Stats) (*Node, error) {
+	info, err := os.Stat(config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newScanner(config, stats)
+	if s.filter, err = newPathFilter(config); err != nil {
+		return nil, err
+	}
+
+	root := newNode(filepath.Base(config.Path), config.Path, info)
+	if root.IsDir() {
+		var ignore *ignoreMatcher
+		if config.Gitignore {
+			if ignore, err = s.loadIgnoreRules(); err != nil {
+				return nil, err
+			}
+		}
+		var chain *dirChain
+		if config.FollowSymlinks {
+			chain = &dirChain{id: dirIdentity(config.Path, info)}
+		}
+		// Unlike the entries below it, a root that cannot be read fails
+		// the scan
+		if root.Children, err = s.scanDir(config.Path, 0, ignore, chain); err != nil {
+			return nil, err
+		}
+	}
+
+	sumTotals(root)
+	stats.Errors = collectErrors(root)
+
+	// History is attached before files are pruned, for the tables
+	if config.GitAge {
+		if err := applyGitHistory(root, config, stats); err != nil {
+			return nil, &gitError{err}
+		}
+	}
+	if !config.ShowFiles {
+		pruneFiles(root)
+	}
+	if config.MaxDepth != -1 && scansFullDepth(config) {
+		trimDepth(root, 0, config.MaxDepth)
+	}
+	sortTree(root, config)
+
+	if config.Git {
+		if err := applyGitStatus(root, config.Path); err != nil {
+			return nil, &gitError{err}
+		}
+	}
+
+	// Concurrent scans append large files in any order
+	sort.Slice(stats.LargeFiles, func(i, j int) bool {
+		return stats.LargeFiles[i].Path < stats.LargeFiles[j].Path
+	})
+	return root, nil
+}
+
+// Scan a directory recursively and return its filtered children.
+// Directories come first, followed by files, each in os.ReadDir order;
+// scanTree sorts them afterwards. With --follow-symlinks, chain holds the
+// directories above, so that links back to one of them are not descended.
+func (s *scanner) scanDir(path string, depth int, ignore *ignoreMatcher, chain *dirChain) ([]*Node, error) {
+	config := s.config
+
+	// Check max depth. When directory sizes are shown or sorted on,
+	// everything is scanned to get complete sizes and the tree is trimmed
+	// afterwards.
+	if config.MaxDepth != -1 && depth > config.MaxDepth && !scansFullDepth(config) {
+		return nil, nil
+	}
+
+	// Read directory entries
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the gitignore rules of this directory
+	if config.Gitignore {
+		ignore = ignore.withFile(filepath.Join(path, ".gitignore"), s.ignorePath(path))
+	}
+
+	// Filter entries
+	var dirs, files []fs.DirEntry
+	for _, entry := range entries {
+		name := entry.Name()
+		// Collapsed version control directories are shown even when hidden
+		// or ignored
+		collapsed := config.CollapseVCS && vcsDirs[name] && entry.IsDir()
+		if !config.ShowHidden && isHidden(name) && !collapsed {
+			continue
+		}
+
+		// Links to directories are treated as directories when followed
+		isDir := entry.IsDir()
+		if config.FollowSymlinks && entry.Type()&fs.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(path, name)); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+
+		if config.Gitignore && !collapsed && s.isGitignored(ignore, filepath.Join(path, name), isDir) {
+			continue
+		}
+
+		// Apply the --exclude patterns, then the --include patterns to files
+		relPath := s.relPath(filepath.Join(path, name))
+		if s.filter.excluded(relPath) || (!isDir && !s.filter.included(relPath)) {
+			continue
+		}
+
+		if isDir {
+			// Check if folder should be excluded
+			if !shouldExcludeFolder(name, config.ExcludeFolders) {
+				dirs = append(dirs, entry)
+			}
+		} else {
+			// Files are always scanned for statistics, even when hidden.
+			// Check if file should be excluded by extension or name
+			if !shouldExcludeFile(name, config.ExcludeFiles, config.ExcludeNames) {
+				files = append(files, entry)
+			}
+		}
+	}
+
+	children := make([]*Node, 0, len(dirs)+len(files))
+
+	// Scan directories, handing them to idle workers when available
+	var wg sync.WaitGroup
+	loops := 0
+	for _, entry := range dirs {
+		entryPath := filepath.Join(path, entry.Name())
+		node := &Node{Name: entry.Name(), Path: entryPath, Mode: fs.ModeDir}
+		if info, err := entry.Info(); err == nil {
+			node = newNode(entry.Name(), entryPath, info)
+		}
+		children = append(children, node)
+
+		// Descend into the link target, unless it is a directory above
+		below := chain
+		if config.FollowSymlinks {
+			info, err := os.Stat(entryPath)
+			if err != nil {
+				node.Err = err
+				continue
+			}
+			if node.IsSymlink() {
+				node.Mode = info.Mode() | fs.ModeSymlink
+			}
+			id := dirIdentity(entryPath, info)
+			if chain.contains(id) {
+				node.LinkLoop = true
+				loops++
+				continue
+			}
+			below = &dirChain{id: id, parent: chain}
+		}
+
+		// Collapsed directories only need their total size
+		if config.CollapseVCS && vcsDirs[node.Name] {
+			node.Collapsed = true
+			node.Size, node.FileCount, node.Err = dirUsage(node.Path)
+			continue
+		}
+
+		select {
+		case s.sem <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-s.sem }()
+				node.Children, node.Err = s.scanDir(node.Path, depth+1, ignore, below)
+			}()
+		default:
+			node.Children, node.Err = s.scanDir(node.Path, depth+1, ignore, below)
+		}
+	}
+
+	// Scan files
+	dirStats := Stats{FileTypes: make(map[string]int64)}
+	for _, entry := range files {
+		entryPath := filepath.Join(path, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			children = append(children, &Node{Name: entry.Name(), Path: entryPath, Err: err})
+			continue
+		}
+
+		node := newNode(entry.Name(), entryPath, info)
+		if s.sniff {
+			node.FileType = classifyFile(entry.Name(), entryPath, node.Mode)
+		} else if fileType, ok := classifyName(entry.Name()); ok {
+			node.FileType = fileType
+		} else {
+			node.FileType = unknownType
+		}
+		fileExt := fileGroup(node, config.GroupBy)
+
+		// Update statistics
+		dirStats.TotalFiles++
+		dirStats.TotalSize += node.Size
+		dirStats.FileTypes[fileExt] += node.Size
+
+		// Track large files for the stat table, JSON and HTML output
+		if config.StatTable || config.Output == "json" || config.Output == "html" {
+			dirStats.LargeFiles = append(dirStats.LargeFiles, FileInfo{
+				Path: entryPath,
+				Size: node.Size,
+				Type: fileExt,
+			})
+		}
+
+		children = append(children, node)
+	}
+	// Links back to a directory above are not entered, so not counted
+	dirStats.TotalDirs = len(dirs) - loops
+	s.mergeStats(dirStats)
+
+	wg.Wait()
+	return children, nil
+}
+
+// Prepare the gitignore rules that apply above the scan root
+func (s *scanner) loadIgnoreRules() (*ignoreMatcher, error) {
+	root, err := findIgnoreRoot(s.config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(s.config.Path)
+	if err != nil {
+		return nil, err
+	}
+	base, err := filepath.Rel(root, abs)
+	if err != nil {
+		return nil, err
+	}
+	if base != "." {
+		s.ignoreBase = filepath.ToSlash(base)
+	}
+
+	return newIgnoreMatcher(root, s.config.Path)
+}
+
+// Get the slash-separated path of a scanned entry relative to the scan root
+func (s *scanner) relPath(entryPath string) string {
+	rel, err := filepath.Rel(s.config.Path, entryPath)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Get the path of a scanned entry relative to the gitignore root
+func (s *scanner) ignorePath(entryPath string) string {
+	return path.Join(s.ignoreBase, s.relPath(entryPath))
+}
+
+// Check whether an entry is excluded by gitignore rules. The .git
+// directory itself is never listed, as in git, unless --collapse-vcs shows
+// it as one entry.
+func (s *scanner) isGitignored(ignore *ignoreMatcher, entryPath string, isDir bool) bool {
+	if isDir && filepath.Base(entryPath) == ".git" {
+		return true
+	}
+	return ignore.isIgnored(s.ignorePath(entryPath), isDir)
+}
+
+// Merge the statistics of one directory into the shared totals
+func (s *scanner) mergeStats(dirStats Stats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.TotalDirs += dirStats.TotalDirs
+	s.stats.TotalFiles += dirStats.TotalFiles
+	s.stats.TotalSize += dirStats.TotalSize
+	for ext, size := range dirStats.FileTypes {
+		s.stats.FileTypes[ext] += size
+	}
+	s.stats.LargeFiles = append(s.stats.LargeFiles, dirStats.LargeFiles...)
+}
+
+// Get the total size and number of files below a directory
+func dirUsage(path string) (int64, int, error) {
+	var size int64
+	var count int
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		count++
+		return nil
+	})
+	return size, count, err
+}
+
+// Get an identifier of a directory that is the same for every path
+// leading to it: its device and inode, or its real path where these are
+// not available
+func dirIdentity(path string, info fs.FileInfo) string {
+	if sys := getSysInfo(info); sys != nil {
+		return fmt.Sprintf("%d:%d", sys.Dev, sys.Inode)
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		if abs, err := filepath.Abs(real); err == nil {
+			return abs
+		}
+	}
+	return path
+}
+
+// Check whether a name is hidden by the dot convention
+func isHidden(name string) bool {
+	return len(name) > 1 && name[0] == '.' && name != ".."
+}
+
+// Set the size and file count of every directory from the files below it.
+// Collapsed directories keep the totals measured during the scan.
+func sumTotals(node *Node) (int64, int) {
+	if node.Collapsed {
+		return node.Size, node.FileCount
+	}
+	if !node.IsDir() {
+		if node.Err != nil {
+			return 0, 0
+		}
+		return node.Size, 1
+	}
+
+	node.Size, node.FileCount = 0, 0
+	for _, child := range node.Children {
+		size, count := sumTotals(child)
+		node.Size += size
+		node.FileCount += count
+	}
+	return node.Size, node.FileCount
+}
+
+// Remove files that were only scanned for statistics and sizes from the tree
+func pruneFiles(node *Node) {
+	dirs := node.Children[:0]
+	for _, child := range node.Children {
+		if child.IsDir() {
+			pruneFiles(child)
+			dirs = append(dirs, child)
+		}
+	}
+	node.Children = dirs
+}
+
+// Check whether the total sizes of directories are shown or sorted on, so
+// that the tree is scanned below --max-depth
+func scansFullDepth(config Config) bool {
+	return config.DiskUsage || config.ShowDirSize || config.Sort == "size" || config.Interactive ||
+		config.Output == "json" || config.Output == "html"
+}
+
+// Remove the children of directories deeper than maxDepth, where depth is
+// the depth of the node's children
+func trimDepth(node *Node, depth int, maxDepth int) {
+	if depth > maxDepth {
+		node.Children = nil
+		return
+	}
+	for _, child := range node.Children {
+		trimDepth(child, depth+1, maxDepth)
+	}
+}
+
+// Create a node from file info
+func newNode(name string, path string, info fs.FileInfo) *Node {
+	node := &Node{
+		Name:    name,
+		Path:    path,
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+		Sys:     getSysInfo(info),
+	}
+	// Directory sizes are summed from their children after the scan
+	if !node.IsDir() {
+		node.Size = info.Size()
+	}
+	if node.IsSymlink() {
+		node.LinkTarget, _ = os.Readlink(path)
+		if _, err := os.Stat(path); err != nil {
+			node.LinkBroken = true
+		}
+	}
+	return node
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// createTestTree creates a temporary directory structure for scanner tests
+func createTestTree(t *testing.T) string {
+	t.Helper()
+
+	tempDir, err := os.MkdirTemp("", "hyperion-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	dirs := []string{
+		"dir1",
+		"dir1/subdir1",
+		"dir1/subdir2",
+		"dir2",
+		"node_modules", // This should be excluded by default
+	}
+
+	files := []string{
+		"file1.txt",
+		"file2.exe", // This could be excluded by extension
+		"dir1/file3.txt",
+		"dir1/subdir1/file4.txt",
+		"dir2/README.md", // This could be excluded by name
+	}
+
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory %s: %v", dir, err)
+		}
+	}
+
+	for _, file := range files {
+		content := []byte("Test content for " + file)
+		if err := os.WriteFile(filepath.Join(tempDir, file), content, 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", file, err)
+		}
+	}
+
+	return tempDir
+}
+
+// testConfig returns the configuration shared by the scanner tests
+func testConfig(path string) Config {
+	return Config{
+		Path:           path,
+		ExcludeFolders: []string{"node_modules"},
+		ShowFiles:      true,
+		ExcludeFiles:   []string{".exe"},
+		ExcludeNames:   []string{"README.md"},
+		MaxDepth:       -1,
+		Unicode:        true,
+		Color:          false,
+		StatTable:      true,
+	}
+}
+
+func newTestStats() Stats {
+	return Stats{
+		FileTypes:  make(map[string]int64),
+		LargeFiles: []FileInfo{},
+	}
+}
+
+func TestScanDir(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	stats := newTestStats()
+
+	if _, err := newScanner(config, &stats).scanDir(config.Path, 0, nil, nil); err != nil {
+		t.Fatalf("scanDir failed: %v", err)
+	}
+
+	// Verify statistics
+	expectedDirs := 4 // dir1, dir1/subdir1, dir1/subdir2, dir2 (excluding node_modules)
+	if stats.TotalDirs != expectedDirs {
+		t.Errorf("Expected %d directories, got %d", expectedDirs, stats.TotalDirs)
+	}
+
+	expectedFiles := 3 // file1.txt, dir1/file3.txt, dir1/subdir1/file4.txt (excluding .exe and README.md)
+	if stats.TotalFiles != expectedFiles {
+		t.Errorf("Expected %d files, got %d", expectedFiles, stats.TotalFiles)
+	}
+
+	// Check that the correct file types were counted
+	if _, exists := stats.FileTypes[".txt"]; !exists {
+		t.Error("Expected .txt in file types, but it wasn't found")
+	}
+
+	if _, exists := stats.FileTypes[".exe"]; exists {
+		t.Error("Found .exe in file types, but it should have been excluded")
+	}
+}
+
+func TestScanTree(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	stats := newTestStats()
+
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	if !root.IsDir() || root.Name != filepath.Base(tempDir) {
+		t.Fatalf("Expected root directory %q, got %q", filepath.Base(tempDir), root.Name)
+	}
+
+	// Directories come before files
+	expected := []string{"dir1", "dir2", "file1.txt"}
+	if len(root.Children) != len(expected) {
+		t.Fatalf("Expected %d root children, got %d", len(expected), len(root.Children))
+	}
+	for i, name := range expected {
+		if root.Children[i].Name != name {
+			t.Errorf("Child %d: expected %q, got %q", i, name, root.Children[i].Name)
+		}
+	}
+
+	file := root.Children[2]
+	if file.IsDir() || file.Size != int64(len("Test content for file1.txt")) {
+		t.Errorf("Expected file1.txt with size %d, got size %d", len("Test content for file1.txt"), file.Size)
+	}
+
+	dir1 := root.Children[0]
+	if len(dir1.Children) != 3 {
+		t.Errorf("Expected 3 children in dir1, got %d", len(dir1.Children))
+	}
+}
+
+func TestScanTreeMissingRoot(t *testing.T) {
+	config := testConfig(filepath.Join(os.TempDir(), "hyperion-does-not-exist"))
+	stats := newTestStats()
+
+	if _, err := scanTree(config, &stats); err == nil {
+		t.Error("Expected an error for a missing root path")
+	}
+}
+
+func TestScanTreeUnreadableRoot(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+	tempDir := createTestTree(t)
+	if err := os.Chmod(tempDir, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(tempDir, 0755)
+
+	config := testConfig(tempDir)
+	stats := newTestStats()
+	if _, err := scanTree(config, &stats); err == nil {
+		t.Error("Expected an error for an unreadable root directory")
+	}
+}
+
+func TestScanTreeParallel(t *testing.T) {
+	tempDir := createTestTree(t)
+
+	// Add enough directories for the workers to overlap
+	for i := 0; i < 20; i++ {
+		dir := filepath.Join(tempDir, "dir1", "subdir2", fmt.Sprintf("p%02d", i))
+		if err := os.MkdirAll(filepath.Join(dir, "nested"), 0755); err != nil {
+			t.Fatalf("Failed to create directory %s: %v", dir, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "nested", "data.bin"), make([]byte, i), 0644); err != nil {
+			t.Fatalf("Failed to create file in %s: %v", dir, err)
+		}
+	}
+
+	config := testConfig(tempDir)
+	sequentialStats := newTestStats()
+	sequential, err := scanTree(config, &sequentialStats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	config.Jobs = 8
+	parallelStats := newTestStats()
+	parallel, err := scanTree(config, &parallelStats)
+	if err != nil {
+		t.Fatalf("scanTree with jobs failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(sequential, parallel) {
+		t.Errorf("Parallel scan produced a different tree than the sequential scan")
+	}
+
+	if !reflect.DeepEqual(sequentialStats, parallelStats) {
+		t.Errorf("Parallel stats %+v differ from sequential stats %+v", parallelStats, sequentialStats)
+	}
+}
+
+func TestSumTotals(t *testing.T) {
+	root := &Node{Name: "root", Mode: fs.ModeDir, Children: []*Node{
+		{Name: "a.txt", Size: 10},
+		{Name: "sub", Mode: fs.ModeDir, Children: []*Node{
+			{Name: "b.txt", Size: 20},
+			{Name: "c.txt", Size: 30},
+		}},
+	}}
+
+	if total, count := sumTotals(root); total != 60 || count != 3 {
+		t.Errorf("Expected total size 60 in 3 files, got %d in %d", total, count)
+	}
+	if root.Children[1].Size != 50 || root.Children[1].FileCount != 2 {
+		t.Errorf("Expected directory size 50 in 2 files, got %d in %d", root.Children[1].Size, root.Children[1].FileCount)
+	}
+}
+
+func TestScanTreeDiskUsage(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	config.ShowFiles = false
+	config.DiskUsage = true
+	config.MaxDepth = 0
+	stats := newTestStats()
+
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	// Files are scanned for their sizes but not kept in the tree
+	if len(root.Children) != 2 {
+		t.Fatalf("Expected 2 directories below the root, got %d", len(root.Children))
+	}
+
+	// Sizes are complete even below --max-depth
+	dir1 := root.Children[0]
+	expected := int64(len("Test content for dir1/file3.txt") + len("Test content for dir1/subdir1/file4.txt"))
+	if dir1.Size != expected || dir1.FileCount != 2 {
+		t.Errorf("Expected dir1 size %d in 2 files, got %d in %d", expected, dir1.Size, dir1.FileCount)
+	}
+	if len(dir1.Children) != 0 {
+		t.Errorf("Expected dir1 to be trimmed at --max-depth, got %d children", len(dir1.Children))
+	}
+}
+
+func TestScanTreeDirSizeMaxDepth(t *testing.T) {
+	tempDir := createTestTree(t)
+	expected := int64(len("Test content for dir1/file3.txt") + len("Test content for dir1/subdir1/file4.txt"))
+
+	// Directory sizes shown or sorted on are complete below --max-depth
+	tests := []struct {
+		name   string
+		update func(config *Config)
+	}{
+		{"--show-dir-size", func(config *Config) { config.ShowDirSize = true }},
+		{"--sort size", func(config *Config) { config.Sort = "size" }},
+	}
+	for _, test := range tests {
+		config := testConfig(tempDir)
+		config.ShowFiles = false
+		config.MaxDepth = 0
+		test.update(&config)
+		stats := newTestStats()
+		root, err := scanTree(config, &stats)
+		if err != nil {
+			t.Fatalf("scanTree failed: %v", err)
+		}
+
+		var dir1 *Node
+		for _, child := range root.Children {
+			if child.Name == "dir1" {
+				dir1 = child
+			}
+		}
+		if dir1 == nil {
+			t.Fatalf("%s: expected dir1 below the root", test.name)
+		}
+		if dir1.Size != expected {
+			t.Errorf("%s: expected dir1 size %d, got %d", test.name, expected, dir1.Size)
+		}
+		if len(dir1.Children) != 0 {
+			t.Errorf("%s: expected dir1 to be trimmed at --max-depth, got %d children", test.name, len(dir1.Children))
+		}
+	}
+}
+
+func TestScanTreeStatsWithoutFiles(t *testing.T) {
+	tempDir := createTestTree(t)
+	config := testConfig(tempDir)
+	config.ShowFiles = false
+	stats := newTestStats()
+
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	// Files are hidden from the tree
+	for _, child := range root.Children {
+		if !child.IsDir() {
+			t.Errorf("Expected only directories in the tree, found file %q", child.Name)
+		}
+	}
+
+	// but still counted, with the file filters applied
+	if stats.TotalFiles != 3 {
+		t.Errorf("Expected 3 files, got %d", stats.TotalFiles)
+	}
+	if stats.TotalSize == 0 || len(stats.LargeFiles) != 3 {
+		t.Errorf("Expected sizes and 3 large files, got %d bytes and %d files", stats.TotalSize, len(stats.LargeFiles))
+	}
+	if _, exists := stats.FileTypes[".exe"]; exists {
+		t.Error("Found .exe in file types, but it should have been excluded")
+	}
+}
+
+func TestScanTreeHidden(t *testing.T) {
+	tempDir := createTestTree(t)
+	for _, file := range []string{".env", ".git/HEAD", "dir1/.cache/data.bin"} {
+		path := filepath.Join(tempDir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", file, err)
+		}
+		if err := os.WriteFile(path, []byte("hidden"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", file, err)
+		}
+	}
+
+	tests := []struct {
+		showHidden bool
+		files      int
+	}{
+		{false, 3},
+		{true, 6},
+	}
+
+	for _, test := range tests {
+		config := testConfig(tempDir)
+		config.ShowHidden = test.showHidden
+		stats := newTestStats()
+		root, err := scanTree(config, &stats)
+		if err != nil {
+			t.Fatalf("scanTree failed: %v", err)
+		}
+
+		found := false
+		var check func(node *Node)
+		check = func(node *Node) {
+			for _, child := range node.Children {
+				if isHidden(child.Name) {
+					found = true
+				}
+				check(child)
+			}
+		}
+		check(root)
+
+		if found != test.showHidden {
+			t.Errorf("ShowHidden %v: expected hidden entries %v, got %v", test.showHidden, test.showHidden, found)
+		}
+		if stats.TotalFiles != test.files {
+			t.Errorf("ShowHidden %v: expected %d files, got %d", test.showHidden, test.files, stats.TotalFiles)
+		}
+	}
+}
+
+func TestScanTreeCollapseVCS(t *testing.T) {
+	tempDir := createTestTree(t)
+	for _, file := range []string{".git/HEAD", ".git/objects/ab/cdef", "dir1/.hg/store"} {
+		path := filepath.Join(tempDir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", file, err)
+		}
+		if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", file, err)
+		}
+	}
+
+	config := testConfig(tempDir)
+	config.ShowHidden = true
+	config.CollapseVCS = true
+	stats := newTestStats()
+	root, err := scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	git := root.Children[0]
+	if git.Name != ".git" || !git.Collapsed {
+		t.Fatalf("Expected a collapsed .git directory first, got %q (collapsed %v)", git.Name, git.Collapsed)
+	}
+	if len(git.Children) != 0 || git.Size != 20 || git.FileCount != 2 {
+		t.Errorf("Expected .git with no children and 20 bytes in 2 files, got %d children and %d bytes in %d files",
+			len(git.Children), git.Size, git.FileCount)
+	}
+
+	// The collapsed contents count toward the parent sizes only
+	if stats.TotalFiles != 3 {
+		t.Errorf("Expected 3 files in the statistics, got %d", stats.TotalFiles)
+	}
+	if root.Size != stats.TotalSize+30 {
+		t.Errorf("Expected root size %d, got %d", stats.TotalSize+30, root.Size)
+	}
+
+	if note := collapsedNote(git); note != " (20 B)" {
+		t.Errorf("collapsedNote(.git): expected %q, got %q", " (20 B)", note)
+	}
+
+	// Collapsed directories are listed without --all too
+	config.ShowHidden = false
+	stats = newTestStats()
+	root, err = scanTree(config, &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+	if git := root.Children[0]; git.Name != ".git" || !git.Collapsed {
+		t.Errorf("Expected a collapsed .git directory without --all, got %q (collapsed %v)", git.Name, git.Collapsed)
+	}
+}
+
+func TestScanTreeFollowSymlinks(t *testing.T) {
+	tempDir := createTestTree(t)
+	links := map[string]string{
+		"link1":           "dir1",
+		"dir1/subdir1/up": "../..",
+		"broken":          "missing",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(tempDir, filepath.FromSlash(name))); err != nil {
+			t.Skipf("Symlinks are not supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		follow bool
+		files  int
+	}{
+		{false, 6},
+		{true, 6},
+	}
+
+	for _, test := range tests {
+		config := testConfig(tempDir)
+		config.FollowSymlinks = test.follow
+		stats := newTestStats()
+		root, err := scanTree(config, &stats)
+		if err != nil {
+			t.Fatalf("scanTree failed: %v", err)
+		}
+
+		nodes := make(map[string]*Node)
+		var collect func(node *Node, prefix string)
+		collect = func(node *Node, prefix string) {
+			for _, child := range node.Children {
+				nodes[prefix+child.Name] = child
+				collect(child, prefix+child.Name+"/")
+			}
+		}
+		collect(root, "")
+
+		link := nodes["link1"]
+		if link == nil || !link.IsSymlink() || link.LinkTarget != "dir1" {
+			t.Fatalf("Follow %v: expected link1 to be a symlink to dir1, got %+v", test.follow, link)
+		}
+		if link.IsDir() != test.follow {
+			t.Errorf("Follow %v: expected link1 directory %v, got %v", test.follow, test.follow, link.IsDir())
+		}
+		if _, ok := nodes["link1/subdir1/file4.txt"]; ok != test.follow {
+			t.Errorf("Follow %v: expected files below link1 %v, got %v", test.follow, test.follow, ok)
+		}
+
+		// The link back to the root is never descended
+		up := nodes["dir1/subdir1/up"]
+		if up == nil || len(up.Children) != 0 || up.LinkLoop != test.follow {
+			t.Errorf("Follow %v: expected up to be a leaf with loop %v, got %+v", test.follow, test.follow, up)
+		}
+
+		if broken := nodes["broken"]; broken == nil || !broken.LinkBroken {
+			t.Errorf("Follow %v: expected broken to be a broken link, got %+v", test.follow, broken)
+		}
+		if stats.TotalFiles != test.files {
+			t.Errorf("Follow %v: expected %d files, got %d", test.follow, test.files, stats.TotalFiles)
+		}
+
+		// Links that are not descended are not counted as directories
+		dirs := 0
+		for _, node := range nodes {
+			if node.IsDir() && !node.LinkLoop {
+				dirs++
+			}
+		}
+		if stats.TotalDirs != dirs {
+			t.Errorf("Follow %v: expected %d directories, got %d", test.follow, dirs, stats.TotalDirs)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// How long to collect changes after the first one before scanning again, so
+// a burst of events causes a single update and steady activity still
+// updates at this interval
+const watchDelay = 200 * time.Millisecond
+
+// watchOp is the kind of change reported by the watcher
+type watchOp int
+
+const (
+	watchCreate watchOp = iota
+	watchDelete
+	watchRename
+	watchModify
+	watchOverflow // Events were lost; the tree must be scanned and watched again
+)
+
+// watchEvent is a filesystem change reported by the watcher
+type watchEvent struct {
+	Op      watchOp
+	Path    string
+	OldPath string
+}
+
+// watchChange is one line of the event log
+type watchChange struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Path    string    `json:"path"`
+	OldPath string    `json:"old_path,omitempty"`
+	Type    string    `json:"type"`
+}
+
+// Scan the tree, then scan it again after every change and either
+// re-render it or log what changed, until interrupted
+func runWatch(config Config) error {
+	w, err := newWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.close()
+
+	root, stats, err := scanWatched(w, config)
+	if err != nil {
+		return err
+	}
+	paths := treePaths(root)
+
+	if config.WatchLog {
+		fmt.Fprintf(os.Stderr, "Watching %s for changes (Ctrl+C to stop)\n", config.Path)
+	} else if err := redraw(root, stats, config); err != nil {
+		return err
+	}
+
+	var pending []watchEvent
+	overflow := false
+	timer := time.NewTimer(watchDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.events:
+			if !ok {
+				return nil
+			}
+			// The delay starts with the first change of a batch, so it is
+			// not pushed back by the ones that follow
+			if len(pending) == 0 {
+				timer.Reset(watchDelay)
+			}
+			pending = append(pending, event)
+			overflow = overflow || event.Op == watchOverflow
+		case err := <-w.errors:
+			return err
+		case <-timer.C:
+			// After lost events the watches may be stale, so they are all
+			// added again by the scan
+			if overflow {
+				w.reset()
+			}
+			root, stats, err = scanWatched(w, config)
+			if err != nil {
+				return err
+			}
+			newPaths := treePaths(root)
+
+			if config.WatchLog {
+				changes := watchChanges(pending, paths, newPaths, config.Path, time.Now())
+				if err := printWatchLog(color.Output, changes, config); err != nil {
+					return err
+				}
+			} else if err := redraw(root, stats, config); err != nil {
+				return err
+			}
+			paths, pending, overflow = newPaths, nil, false
+		}
+	}
+}
+
+// Scan the tree and watch all of its directories
+func scanWatched(w *watcher, config Config) (*Node, Stats, error) {
+	stats := Stats{
+		FileTypes:  make(map[string]int64),
+		LargeFiles: []FileInfo{},
+	}
+	root, err := scanTree(config, &stats)
+	if isGitError(err) {
+		return nil, stats, err
+	}
+	if err != nil {
+		return nil, stats, fmt.Errorf("error accessing path %s: %v", config.Path, err)
+	}
+	if err := w.addTree(root); err != nil {
+		return nil, stats, err
+	}
+	return root, stats, nil
+}
+
+// Clear the screen and print the tree again
+func redraw(root *Node, stats Stats, config Config) error {
+	if config.Output == "text" {
+		fmt.Fprint(os.Stdout, "\x1b[H\x1b[2J")
+	}
+	if err := printOutput(root, stats, config); err != nil {
+		return err
+	}
+	if config.Output == "text" {
+		fmt.Printf("\nWatching %s for changes (Ctrl+C to stop)\n", config.Path)
+	}
+	return nil
+}
+
+// Collect the paths in a tree, mapped to whether they are directories
+func treePaths(root *Node) map[string]bool {
+	paths := make(map[string]bool)
+	var collect func(node *Node)
+	collect = func(node *Node) {
+		paths[node.Path] = node.IsDir()
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	collect(root)
+	return paths
+}
+
+// Work out what changed between two scans. Created and deleted entries
+// come from comparing the scans, so the filters apply and nothing is missed
+// while new directories are not watched yet; the events add renames and
+// modified files. Entries inside a created, deleted or renamed directory
+// are not listed separately.
+func watchChanges(events []watchEvent, oldPaths, newPaths map[string]bool, root string, now time.Time) []watchChange {
+	var changes []watchChange
+	var covered []string
+
+	add := func(event, path, oldPath string, isDir bool) {
+		change := watchChange{Time: now, Event: event, Path: relWatchPath(root, path), Type: "file"}
+		if oldPath != "" {
+			change.OldPath = relWatchPath(root, oldPath)
+		}
+		if isDir {
+			change.Type = "directory"
+			covered = append(covered, path, oldPath)
+		}
+		changes = append(changes, change)
+	}
+	isCovered := func(path string) bool {
+		for _, dir := range covered {
+			if dir != "" && strings.HasPrefix(path, dir+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Renames within the tree
+	renamedFrom := make(map[string]bool)
+	renamedTo := make(map[string]bool)
+	for _, event := range events {
+		if event.Op != watchRename {
+			continue
+		}
+		_, wasShown := oldPaths[event.OldPath]
+		isDir, isShown := newPaths[event.Path]
+		if wasShown && isShown {
+			add("renamed", event.Path, event.OldPath, isDir)
+			renamedFrom[event.OldPath] = true
+			renamedTo[event.Path] = true
+		}
+	}
+
+	var created, deleted []string
+	for path := range newPaths {
+		if _, ok := oldPaths[path]; !ok && !renamedTo[path] {
+			created = append(created, path)
+		}
+	}
+	for path := range oldPaths {
+		if _, ok := newPaths[path]; !ok && !renamedFrom[path] {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(created)
+	sort.Strings(deleted)
+
+	for _, path := range created {
+		if !isCovered(path) {
+			add("created", path, "", newPaths[path])
+		}
+	}
+	for _, path := range deleted {
+		if !isCovered(path) {
+			add("deleted", path, "", oldPaths[path])
+		}
+	}
+
+	// Files written again, reported once per scan
+	modified := make(map[string]bool)
+	for _, event := range events {
+		if event.Op != watchModify || modified[event.Path] {
+			continue
+		}
+		isDir, isShown := newPaths[event.Path]
+		if _, wasShown := oldPaths[event.Path]; wasShown && isShown && !isDir {
+			modified[event.Path] = true
+			add("modified", event.Path, "", false)
+		}
+	}
+
+	return changes
+}
+
+// Get a path relative to the watched root
+func relWatchPath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// Print changes as log lines, or as one JSON object per line
+func printWatchLog(w io.Writer, changes []watchChange, config Config) error {
+	if config.Output == "json" {
+		encoder := json.NewEncoder(w)
+		for _, change := range changes {
+			if err := encoder.Encode(change); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, change := range changes {
+		path, oldPath := change.Path, change.OldPath
+		if change.Type == "directory" {
+			path += string(filepath.Separator)
+			oldPath += string(filepath.Separator)
+		}
+		if change.OldPath != "" {
+			path = oldPath + " -> " + path
+		}
+
+		line := fmt.Sprintf("%-8s %s", change.Event, path)
+		if config.Color {
+			line = watchColor(change.Event).Sprint(line)
+		}
+		fmt.Fprintf(w, "%s %s\n", change.Time.Format("15:04:05"), line)
+	}
+	return nil
+}
+
+// Get the color of an event, matching the diff colors
+func watchColor(event string) *color.Color {
+	switch event {
+	case "created":
+		return newColor(color.FgGreen)
+	case "deleted":
+		return newColor(color.FgRed)
+	case "renamed":
+		return newColor(color.FgCyan)
+	default:
+		return newColor(color.FgYellow)
+	}
+}
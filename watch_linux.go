@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Events watched on every directory of the tree
+const watchMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO |
+	unix.IN_CLOSE_WRITE | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF | unix.IN_ONLYDIR
+
+// watcher reports changes below a set of directories using inotify
+type watcher struct {
+	fd     int
+	file   *os.File
+	mu     sync.Mutex
+	dirs   map[int]string
+	events chan watchEvent
+	errors chan error
+}
+
+// Create an inotify watcher
+func newWatcher() (*watcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start watching: %v", err)
+	}
+
+	w := &watcher{
+		// A non-blocking descriptor lets reads wait in the runtime poller,
+		// so closing the file stops the reader. Watches are added with the
+		// raw descriptor, as Fd can put a file back in blocking mode.
+		fd:     fd,
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		dirs:   make(map[int]string),
+		events: make(chan watchEvent),
+		errors: make(chan error, 1),
+	}
+	go w.readEvents()
+	return w, nil
+}
+
+// Watch every directory of a scanned tree. Directories that are already
+// watched are skipped by the kernel, so this can be called after each scan.
+func (w *watcher) addTree(node *Node) error {
+	if !node.IsDir() {
+		return nil
+	}
+
+	wd, err := unix.InotifyAddWatch(w.fd, node.Path, watchMask)
+	switch {
+	case errors.Is(err, unix.ENOSPC):
+		return fmt.Errorf("too many directories to watch, raise fs.inotify.max_user_watches")
+	case err == nil:
+		w.mu.Lock()
+		w.dirs[wd] = node.Path
+		w.mu.Unlock()
+	}
+	// Other errors mean the directory is gone or unreadable; the next scan
+	// picks up the change
+
+	for _, child := range node.Children {
+		if err := w.addTree(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove all watches, before the tree is watched again
+func (w *watcher) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for wd := range w.dirs {
+		unix.InotifyRmWatch(w.fd, uint32(wd))
+	}
+	w.dirs = make(map[int]string)
+}
+
+// Stop watching
+func (w *watcher) close() {
+	w.file.Close()
+}
+
+// Read inotify events until the watcher is closed
+func (w *watcher) readEvents() {
+	defer close(w.events)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				w.errors <- err
+			}
+			return
+		}
+		for _, event := range w.parseEvents(buf[:n]) {
+			w.events <- event
+		}
+	}
+}
+
+// Decode a buffer of inotify events. A rename shows up as a moved-from and
+// a moved-to event with the same cookie; a move with only one side in the
+// tree is reported as a delete or a create.
+func (w *watcher) parseEvents(buf []byte) []watchEvent {
+	var events []watchEvent
+	movedFrom := make(map[uint32]string)
+
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buf); {
+		raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + unix.SizeofInotifyEvent
+		name := strings.TrimRight(string(buf[nameStart:nameStart+int(raw.Len)]), "\x00")
+		offset = nameStart + int(raw.Len)
+
+		// The kernel queue was full and events were dropped
+		if raw.Mask&unix.IN_Q_OVERFLOW != 0 {
+			events = append(events, watchEvent{Op: watchOverflow})
+			continue
+		}
+
+		w.mu.Lock()
+		dir := w.dirs[int(raw.Wd)]
+		if raw.Mask&unix.IN_IGNORED != 0 {
+			delete(w.dirs, int(raw.Wd))
+		}
+		w.mu.Unlock()
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+
+		switch {
+		case raw.Mask&unix.IN_MOVED_FROM != 0:
+			movedFrom[raw.Cookie] = path
+		case raw.Mask&unix.IN_MOVED_TO != 0:
+			if oldPath, ok := movedFrom[raw.Cookie]; ok {
+				delete(movedFrom, raw.Cookie)
+				events = append(events, watchEvent{Op: watchRename, Path: path, OldPath: oldPath})
+			} else {
+				events = append(events, watchEvent{Op: watchCreate, Path: path})
+			}
+		case raw.Mask&unix.IN_CREATE != 0:
+			events = append(events, watchEvent{Op: watchCreate, Path: path})
+		case raw.Mask&(unix.IN_DELETE|unix.IN_DELETE_SELF|unix.IN_MOVE_SELF) != 0:
+			events = append(events, watchEvent{Op: watchDelete, Path: path})
+		case raw.Mask&unix.IN_CLOSE_WRITE != 0:
+			events = append(events, watchEvent{Op: watchModify, Path: path})
+		}
+	}
+
+	for _, oldPath := range movedFrom {
+		events = append(events, watchEvent{Op: watchDelete, Path: oldPath})
+	}
+	return events
+}
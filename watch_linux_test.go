@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestWatcherEvents(t *testing.T) {
+	tempDir := createTestTree(t)
+	stats := newTestStats()
+	root, err := scanTree(testConfig(tempDir), &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	w, err := newWatcher()
+	if err != nil {
+		t.Fatalf("newWatcher failed: %v", err)
+	}
+	defer w.close()
+	if err := w.addTree(root); err != nil {
+		t.Fatalf("addTree failed: %v", err)
+	}
+
+	oldPath := filepath.Join(tempDir, "dir1", "file3.txt")
+	newPath := filepath.Join(tempDir, "dir1", "subdir2", "moved.txt")
+	if err := os.Rename(oldPath, newPath); err != nil {
+		t.Fatalf("Failed to rename file: %v", err)
+	}
+
+	select {
+	case event := <-w.events:
+		expected := watchEvent{Op: watchRename, Path: newPath, OldPath: oldPath}
+		if event != expected {
+			t.Errorf("Expected event %+v, got %+v", expected, event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the rename event")
+	}
+}
+
+func TestWatcherClose(t *testing.T) {
+	tempDir := createTestTree(t)
+	stats := newTestStats()
+	root, err := scanTree(testConfig(tempDir), &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	w, err := newWatcher()
+	if err != nil {
+		t.Fatalf("newWatcher failed: %v", err)
+	}
+	if err := w.addTree(root); err != nil {
+		t.Fatalf("addTree failed: %v", err)
+	}
+
+	// Let the reader go back to waiting after an event, then close: only a
+	// non-blocking descriptor lets that wait end
+	if err := os.Mkdir(filepath.Join(tempDir, "new"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	select {
+	case <-w.events:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the create event")
+	}
+
+	w.close()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-w.events:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Timed out waiting for the reader to stop after close")
+		}
+	}
+}
+
+func TestWatcherOverflow(t *testing.T) {
+	tempDir := createTestTree(t)
+	stats := newTestStats()
+	root, err := scanTree(testConfig(tempDir), &stats)
+	if err != nil {
+		t.Fatalf("scanTree failed: %v", err)
+	}
+
+	w, err := newWatcher()
+	if err != nil {
+		t.Fatalf("newWatcher failed: %v", err)
+	}
+	defer w.close()
+	if err := w.addTree(root); err != nil {
+		t.Fatalf("addTree failed: %v", err)
+	}
+
+	// An overflow has no watch descriptor but is still reported
+	buf := make([]byte, unix.SizeofInotifyEvent)
+	raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[0]))
+	raw.Wd = -1
+	raw.Mask = unix.IN_Q_OVERFLOW
+	events := w.parseEvents(buf)
+	if len(events) != 1 || events[0].Op != watchOverflow {
+		t.Fatalf("Expected an overflow event, got %+v", events)
+	}
+
+	// The tree is watched again after a reset
+	w.reset()
+	if err := w.addTree(root); err != nil {
+		t.Fatalf("addTree failed: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		select {
+		case event := <-w.events:
+			if event.Op == watchCreate && event.Path == filepath.Join(tempDir, "new") {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for the create event after a reset")
+		}
+	}
+}
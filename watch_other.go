@@ -0,0 +1,27 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// watcher is not implemented on this platform
+type watcher struct {
+	events chan watchEvent
+	errors chan error
+}
+
+// Watching relies on inotify, which only Linux provides
+func newWatcher() (*watcher, error) {
+	return nil, fmt.Errorf("--watch is not supported on %s", runtime.GOOS)
+}
+
+func (w *watcher) addTree(node *Node) error {
+	return nil
+}
+
+func (w *watcher) reset() {}
+
+func (w *watcher) close() {}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchChanges(t *testing.T) {
+	root := "/watched"
+	p := func(rel string) string { return filepath.Join(root, rel) }
+
+	oldPaths := map[string]bool{
+		root:               true,
+		p("keep.txt"):      false,
+		p("old.txt"):       false,
+		p("gone"):          true,
+		p("gone/file.txt"): false,
+		p("dir"):           true,
+		p("dir/inner.txt"): false,
+	}
+	newPaths := map[string]bool{
+		root:                   true,
+		p("keep.txt"):          false,
+		p("new.txt"):           false,
+		p("added"):             true,
+		p("added/file.txt"):    false,
+		p("renamed"):           true,
+		p("renamed/inner.txt"): false,
+	}
+	events := []watchEvent{
+		{Op: watchRename, Path: p("renamed"), OldPath: p("dir")},
+		{Op: watchModify, Path: p("keep.txt")},
+		{Op: watchModify, Path: p("keep.txt")},
+		{Op: watchModify, Path: p("new.txt")},
+		{Op: watchRename, Path: p("new.txt"), OldPath: p("old.txt")},
+	}
+
+	var result []string
+	for _, change := range watchChanges(events, oldPaths, newPaths, root, time.Time{}) {
+		result = append(result, change.Event+" "+change.OldPath+" "+change.Path+" "+change.Type)
+	}
+
+	expected := []string{
+		"renamed dir renamed directory",
+		"renamed old.txt new.txt file",
+		"created  added directory",
+		"deleted  gone directory",
+		"modified  keep.txt file",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("watchChanges: expected %q, got %q", expected, result)
+	}
+}
+
+func TestPrintWatchLog(t *testing.T) {
+	now := time.Date(2024, 5, 1, 14, 30, 5, 0, time.UTC)
+	changes := []watchChange{
+		{Time: now, Event: "created", Path: "a.txt", Type: "file"},
+		{Time: now, Event: "renamed", Path: "new", OldPath: "old", Type: "directory"},
+	}
+
+	var buf bytes.Buffer
+	if err := printWatchLog(&buf, changes, Config{Output: "text"}); err != nil {
+		t.Fatalf("printWatchLog failed: %v", err)
+	}
+	sep := string(filepath.Separator)
+	expected := "14:30:05 created  a.txt\n14:30:05 renamed  old" + sep + " -> new" + sep + "\n"
+	if buf.String() != expected {
+		t.Errorf("printWatchLog: expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := printWatchLog(&buf, changes[:1], Config{Output: "json"}); err != nil {
+		t.Fatalf("printWatchLog failed: %v", err)
+	}
+	expected = `{"time":"2024-05-01T14:30:05Z","event":"created","path":"a.txt","type":"file"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("printWatchLog(json): expected %q, got %q", expected, buf.String())
+	}
+}